@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/app"
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+// runCommand - runs CLI subcommand instead of the main application loop
+func runCommand(opts app.Opts, args []string) {
+	switch args[0] {
+	case "camlogs":
+		runCamLogs(opts, args[1:])
+	case "diag":
+		runDiag(opts, args[1:])
+	case "console":
+		runConsole(opts, args[1:])
+	case "sensors":
+		runSensors(opts, args[1:])
+	case "replay":
+		runReplay(opts, args[1:])
+	default:
+		log.Fatal().Str("command", args[0]).Msg("Unknown command")
+	}
+}
+
+// nanit camlogs [-timeout 10m] <baby>
+func runCamLogs(opts app.Opts, args []string) {
+	fs := flag.NewFlagSet("camlogs", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 10*time.Minute, "How long to wait for the cam to connect and upload the logs")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: nanit camlogs [-timeout 10m] <baby_uid_or_name>")
+		os.Exit(2)
+	}
+
+	// Log receiver is started by the command itself
+	opts.HTTPEnabled = false
+
+	dir, err := app.NewApp(opts).CollectCamLogs(fs.Arg(0), *timeout)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to collect cam logs")
+		os.Exit(1)
+	}
+
+	log.Info().Str("dir", dir).Msg("Cam logs collected")
+}
+
+// nanit diag [-timeout 2m] network <baby>
+func runDiag(opts app.Opts, args []string) {
+	fs := flag.NewFlagSet("diag", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 2*time.Minute, "How long to wait for the cam to connect and report")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 || fs.Arg(0) != "network" {
+		fmt.Fprintln(os.Stderr, "Usage: nanit diag [-timeout 2m] network <baby_uid_or_name>")
+		os.Exit(2)
+	}
+
+	instance := app.NewApp(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var diag *app.NetworkDiagnostics
+	err := instance.RunCommand(ctx, fs.Arg(1), func(_ context.Context, babyUID string, _ *client.WebsocketConnection) error {
+		var err error
+		diag, err = instance.CheckNetwork(babyUID)
+		return err
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to check cam network")
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(diag)
+}
+
+// nanit sensors [-timeout 2m] <baby> [settings_json]
+func runSensors(opts app.Opts, args []string) {
+	fs := flag.NewFlagSet("sensors", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 2*time.Minute, "How long to wait for the cam to connect and confirm the settings")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 && fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: nanit sensors [-timeout 2m] <baby_uid_or_name> [settings_json]")
+		os.Exit(2)
+	}
+
+	var updates []*client.Settings_SensorSettings
+	if fs.NArg() == 2 {
+		var err error
+		updates, err = app.ParseSensorSettings(strings.NewReader(fs.Arg(1)))
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid sensor settings")
+			os.Exit(2)
+		}
+	}
+
+	instance := app.NewApp(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var sensors []*client.Settings_SensorSettings
+	err := instance.RunCommand(ctx, fs.Arg(0), func(ctx context.Context, babyUID string, _ *client.WebsocketConnection) error {
+		var err error
+		sensors, err = instance.FetchSensorSettings(ctx, babyUID)
+		if err != nil || len(updates) == 0 {
+			return err
+		}
+
+		if err := instance.UpdateSensorSettings(babyUID, updates...); err != nil {
+			return err
+		}
+
+		sensors = instance.GetSensorSettings(babyUID)
+		return nil
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to retrieve sensor settings")
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(app.SensorSettingsAsMap(sensors))
+}
+
+// nanit replay <baby_uid> <recording.jsonl>
+func runReplay(opts app.Opts, args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: nanit replay <baby_uid> <recording.jsonl>")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(args[1])
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to open recording")
+	}
+
+	defer f.Close()
+
+	// Replay must not alter the session of the running app
+	opts.SessionFile = ""
+	opts.MQTT = nil
+
+	instance := app.NewApp(opts)
+	if err := instance.ReplayRecording(args[0], f); err != nil {
+		log.Error().Err(err).Msg("Unable to replay recording")
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(instance.BabyStateManager.GetBabyState(args[0]).AsMap(true))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gregory-m/nanit/pkg/app"
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+const consoleHelp = `Enter requests as: <REQUEST_TYPE> [JSON request body]
+  ie. GET_STATUS {"getStatus": {"all": true}}
+      PUT_CONTROL {"control": {"nightLight": "LIGHT_ON"}}
+Commands: help, types, quit`
+
+var consoleJSON = protojson.MarshalOptions{Multiline: true, Indent: "  ", AllowPartial: true}
+
+// nanit console [-timeout 2m] <baby>
+func runConsole(opts app.Opts, args []string) {
+	fs := flag.NewFlagSet("console", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 2*time.Minute, "How long to wait for the cam to connect")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: nanit console [-timeout 2m] <baby_uid_or_name>")
+		os.Exit(2)
+	}
+
+	// Timeout applies only until connected, the console itself runs until quit
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connectTimer := time.AfterFunc(*timeout, cancel)
+
+	err := app.NewApp(opts).RunCommand(ctx, fs.Arg(0), func(_ context.Context, babyUID string, conn *client.WebsocketConnection) error {
+		if !connectTimer.Stop() {
+			return ctx.Err()
+		}
+
+		var outMu sync.Mutex
+		printf := func(format string, a ...interface{}) {
+			outMu.Lock()
+			fmt.Printf(format, a...)
+			outMu.Unlock()
+		}
+
+		conn.RegisterMessageHandler(func(m *client.Message, _ *client.WebsocketConnection) {
+			if m.GetType() == client.Message_KEEPALIVE {
+				return
+			}
+
+			printf("\n< %v\n", formatConsoleMessage(m))
+		})
+
+		printf("Connected to the cam of baby %v\n%v\n", babyUID, consoleHelp)
+
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			switch line {
+			case "":
+				continue
+			case "quit", "exit":
+				return nil
+			case "help":
+				printf("%v\n", consoleHelp)
+				continue
+			case "types":
+				printf("%v\n", strings.Join(consoleRequestTypes(), " "))
+				continue
+			}
+
+			reqType, req, err := parseConsoleRequest(line)
+			if err != nil {
+				printf("Invalid request: %v\n", err)
+				continue
+			}
+
+			printf("> %v %v\n", reqType, formatConsoleProto(req))
+
+			// Response itself is printed by the message handler, we only report failures
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+
+				if _, err := conn.Do(ctx, reqType, req); err != nil {
+					printf("\n! %v request failed: %v\n", reqType, err)
+				}
+			}()
+		}
+
+		return scanner.Err()
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Console terminated")
+		os.Exit(1)
+	}
+}
+
+// parseConsoleRequest - parses "<REQUEST_TYPE> [JSON]" line into request
+func parseConsoleRequest(line string) (client.RequestType, *client.Request, error) {
+	parts := strings.SplitN(line, " ", 2)
+
+	typeValue, ok := client.RequestType_value[strings.ToUpper(parts[0])]
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown request type %q (see types)", parts[0])
+	}
+
+	reqType := client.RequestType(typeValue)
+	req := &client.Request{}
+
+	if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+		if err := (protojson.UnmarshalOptions{AllowPartial: true}).Unmarshal([]byte(parts[1]), req); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Id and type are filled in when sending, we just need to check the rest of the required fields
+	req.Id = new(int32)
+	req.Type = reqType.Enum()
+	if err := proto.CheckInitialized(req); err != nil {
+		return 0, nil, err
+	}
+
+	return reqType, req, nil
+}
+
+func consoleRequestTypes() []string {
+	names := make([]string, 0, len(client.RequestType_value))
+	for name := range client.RequestType_value {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func formatConsoleProto(m proto.Message) string {
+	b, err := consoleJSON.Marshal(m)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+
+	return string(b)
+}
+
+// formatConsoleMessage - message as JSON, followed by fields not covered by the proto file
+func formatConsoleMessage(m *client.Message) string {
+	s := formatConsoleProto(m)
+
+	var unknown []client.RawField
+	if m.Response != nil {
+		unknown = client.GetUnknownFields(m.Response)
+	} else if m.Request != nil {
+		unknown = client.GetUnknownFields(m.Request)
+	}
+
+	if len(unknown) > 0 {
+		s += fmt.Sprintf("\n  unknown fields: %+v", unknown)
+	}
+
+	return s
+}
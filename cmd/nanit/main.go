@@ -1,7 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"regexp"
@@ -10,6 +13,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/gregory-m/nanit/pkg/app"
+	"github.com/gregory-m/nanit/pkg/client"
 	"github.com/gregory-m/nanit/pkg/mqtt"
 	"github.com/gregory-m/nanit/pkg/utils"
 )
@@ -35,7 +39,8 @@ func main() {
 		},
 		SessionFile:     utils.EnvVarStr("NANIT_SESSION_FILE", "data/session.json"),
 		DataDirectories: ensureDataDirectories(),
-		HTTPEnabled:     false,
+		HTTPEnabled:     utils.EnvVarBool("NANIT_HTTP_ENABLED", false),
+		HTTPPublicAddr:  utils.EnvVarStr("NANIT_HTTP_ADDR", ""),
 		EventPolling: app.EventPollingOpts{
 			// Event message polling disabled by default
 			Enabled: utils.EnvVarBool("NANIT_EVENTS_POLLING", false),
@@ -44,6 +49,88 @@ func main() {
 			// 300 second (5 min) default message timeout (unseen messages are ignored once they are this old)
 			MessageTimeout: utils.EnvVarSeconds("NANIT_EVENTS_MESSAGE_TIMEOUT", 300*time.Second),
 		},
+		EventsWebhookURL: utils.EnvVarStr("NANIT_EVENTS_WEBHOOK_URL", ""),
+		// 5 minute default cam status polling interval
+		StatusPollingInterval: utils.EnvVarSeconds("NANIT_STATUS_POLLING_INTERVAL", 300*time.Second),
+		// Network checks disabled by default
+		NetworkPollingInterval: utils.EnvVarSeconds("NANIT_NETWORK_POLLING_INTERVAL", 0),
+		LocalCamAddrs:          utils.EnvVarMap("NANIT_LOCAL_CAM_ADDRS"),
+		// Cams use self-signed certificates
+		LocalCamSkipTLSVerify:    utils.EnvVarBool("NANIT_LOCAL_CAM_SKIP_TLS_VERIFY", true),
+		LocalCamCertFingerprints: utils.EnvVarMap("NANIT_LOCAL_CAM_CERT_FINGERPRINTS"),
+		Websocket: client.TransportOpts{
+			DialTimeout: utils.EnvVarSeconds("NANIT_WEBSOCKET_DIAL_TIMEOUT", 30*time.Second),
+		},
+		KeepaliveTimeout:   utils.EnvVarSeconds("NANIT_KEEPALIVE_TIMEOUT", client.DefaultKeepaliveTimeout),
+		BabyStreamProfiles: utils.EnvVarMap("NANIT_STREAM_PROFILE"),
+		// Websocket traffic recording disabled by default
+		RecordDir: utils.EnvVarStr("NANIT_RECORD_DIR", ""),
+		// Inspection of received messages disabled by default
+		InspectReport: utils.EnvVarStr("NANIT_INSPECT_REPORT", ""),
+	}
+
+	if profilesJSON := utils.EnvVarStr("NANIT_STREAM_PROFILES", ""); profilesJSON != "" {
+		if err := json.Unmarshal([]byte(profilesJSON), &opts.StreamProfiles); err != nil {
+			log.Fatal().Err(err).Msg("Unable to decode NANIT_STREAM_PROFILES")
+		}
+	}
+
+	localStreamID, err := app.ParseStreamIdentifier(utils.EnvVarStr("NANIT_LOCAL_STREAM_IDENTIFIER", "mobile"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid NANIT_LOCAL_STREAM_IDENTIFIER")
+	}
+
+	opts.LocalStreamIdentifier = &localStreamID
+
+	for name, targetURL := range utils.EnvVarMap("NANIT_STREAM_TARGETS") {
+		streamID, err := app.ParseStreamIdentifier(name)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid NANIT_STREAM_TARGETS")
+		} else if streamID == localStreamID {
+			log.Fatal().Stringer("stream_id", streamID).Msg("Stream identifier of NANIT_STREAM_TARGETS is already used for local streaming, see NANIT_LOCAL_STREAM_IDENTIFIER")
+		}
+
+		if opts.StreamTargets == nil {
+			opts.StreamTargets = make(map[client.StreamIdentifier]string)
+		}
+
+		opts.StreamTargets[streamID] = targetURL
+	}
+
+	for babyUID, sensors := range utils.EnvVarMap("NANIT_SENSOR_DATA_TRANSFER") {
+		transfer, err := app.ParseSensorDataTransfer(sensors)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid NANIT_SENSOR_DATA_TRANSFER")
+		}
+
+		if opts.SensorDataTransfer == nil {
+			opts.SensorDataTransfer = make(map[string]app.SensorDataTransfer)
+		}
+
+		opts.SensorDataTransfer[babyUID] = transfer
+	}
+
+	// Proxy is taken from HTTPS_PROXY / NO_PROXY environment variables unless set explicitly
+	if proxy := utils.EnvVarStr("NANIT_WEBSOCKET_PROXY", ""); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid NANIT_WEBSOCKET_PROXY")
+		}
+
+		opts.Websocket.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	for babyUID, addr := range opts.LocalCamAddrs {
+		// Cam listens for local websocket connections on port 442
+		if !regexp.MustCompile("(:[0-9]+)$").MatchString(addr) {
+			opts.LocalCamAddrs[babyUID] = addr + ":442"
+		}
+	}
+
+	// Subcommands only talk to the cam, they don't need RTMP / MQTT
+	if flag.NArg() > 0 {
+		runCommand(opts, flag.Args())
+		return
 	}
 
 	if utils.EnvVarBool("NANIT_RTMP_ENABLED", true) {
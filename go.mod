@@ -5,11 +5,10 @@ go 1.14
 require (
 	github.com/eclipse/paho.mqtt.golang v1.3.0
 	github.com/golang/protobuf v1.4.3
+	github.com/gorilla/websocket v1.4.2
 	github.com/joho/godotenv v1.3.0
 	github.com/notedit/rtmp v0.0.2
 	github.com/rs/zerolog v1.20.0
-	github.com/sacOO7/go-logger v0.0.0-20180719173527-9ac9add5a50d // indirect
-	github.com/sacOO7/gowebsocket v0.0.0-20201031204121-1620b8bfa516
 	github.com/stretchr/testify v1.6.1
 	golang.org/x/term v0.11.0
 	google.golang.org/protobuf v1.25.0
@@ -1,10 +1,15 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/rs/zerolog/log"
+
 	"github.com/gregory-m/nanit/pkg/baby"
 	"github.com/gregory-m/nanit/pkg/client"
 	"github.com/gregory-m/nanit/pkg/message"
@@ -21,8 +26,29 @@ type App struct {
 	BabyStateManager *baby.StateManager
 	RestClient       *client.NanitClient
 	MQTTConnection   *mqtt.Connection
+
+	websocketsMu sync.RWMutex
+	websockets   map[string]*client.WebsocketConnectionManager
+
+	nightLightTimersMu sync.Mutex
+	nightLightTimers   map[string]*time.Timer
+
+	settingsMu sync.RWMutex
+	settings   map[string]*client.Settings
+
+	camLogsWaiters camLogsWaiters
+
+	firmwareMu sync.Mutex
+
+	bandwidthMu sync.RWMutex
+	bandwidth   map[string]*Bandwidth
+
+	inspector *client.Inspector
 }
 
+var errWebsocketNotReady = errors.New("Websocket connection to the cam is not ready")
+var errSettingsUnknown = errors.New("Cam settings have not been received yet")
+
 // NewApp - constructor
 func NewApp(opts Opts) *App {
 	sessionStore := session.InitSessionStore(opts.SessionFile)
@@ -35,22 +61,45 @@ func NewApp(opts Opts) *App {
 			RefreshToken: opts.NanitCredentials.RefreshToken,
 			SessionStore: sessionStore,
 		},
+		websockets:       make(map[string]*client.WebsocketConnectionManager),
+		nightLightTimers: make(map[string]*time.Timer),
+		settings:         make(map[string]*client.Settings),
+		bandwidth:        make(map[string]*Bandwidth),
+		inspector:        openInspector(opts.InspectReport),
 	}
 
 	if opts.MQTT != nil {
 		instance.MQTTConnection = mqtt.NewConnection(*opts.MQTT)
+		instance.MQTTConnection.RegisterCommandHandler("night_light", instance.handleNightLightCommand)
+		instance.registerSettingsCommands()
+		instance.MQTTConnection.RegisterCommandHandler("playback", instance.handlePlaybackCommand)
+		instance.MQTTConnection.RegisterCommandHandler("stream_profile", instance.handleStreamProfileCommand)
 	}
 
 	return instance
 }
 
+// authorize - reauthorizes if we don't have a token or we assume it is invalid
+// Failure is fatal only if we don't know the babies yet, otherwise we can still reach the cams over LAN
+func (app *App) authorize() {
+	if err := app.RestClient.MaybeAuthorize(false); err != nil {
+		if len(app.RestClient.GetBabies()) == 0 {
+			log.Fatal().Err(err).Msg("Unable to authorize")
+		}
+
+		log.Error().Err(err).Msg("Unable to authorize, continuing with the stored session")
+	}
+}
+
 // Run - application main loop
 func (app *App) Run(ctx utils.GracefulContext) {
-	// Reauthorize if we don't have a token or we assume it is invalid
-	app.RestClient.MaybeAuthorize(false)
+	app.authorize()
 
 	// Fetches babies info if they are not present in session
-	app.RestClient.EnsureBabies()
+	babies, err := app.RestClient.EnsureBabies()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to fetch babies")
+	}
 
 	// RTMP
 	if app.Opts.RTMP != nil {
@@ -64,8 +113,14 @@ func (app *App) Run(ctx utils.GracefulContext) {
 		})
 	}
 
+	// Events webhook
+	if app.Opts.EventsWebhookURL != "" {
+		unsubscribe := app.runEventsWebhook(app.Opts.EventsWebhookURL)
+		defer unsubscribe()
+	}
+
 	// Start reading the data from the stream
-	for _, babyInfo := range app.SessionStore.Session.Babies {
+	for _, babyInfo := range babies {
 		_babyInfo := babyInfo
 		ctx.RunAsChild(func(childCtx utils.GracefulContext) {
 			app.handleBaby(_babyInfo, childCtx)
@@ -74,16 +129,32 @@ func (app *App) Run(ctx utils.GracefulContext) {
 
 	// Start serving content over HTTP
 	if app.Opts.HTTPEnabled {
-		go serve(app.SessionStore.Session.Babies, app.Opts.DataDirectories)
+		go app.serve()
 	}
 
 	<-ctx.Done()
+	app.flushInspector()
 }
 
 func (app *App) handleBaby(baby baby.Baby, ctx utils.GracefulContext) {
-	if app.Opts.RTMP != nil || app.MQTTConnection != nil {
+	if app.Opts.RTMP != nil || app.MQTTConnection != nil || app.Opts.HTTPEnabled {
 		// Websocket connection
-		ws := client.NewWebsocketConnectionManager(baby.UID, baby.CameraUID, app.SessionStore.Session, app.RestClient, app.BabyStateManager)
+		ws := app.newWebsocketManager(baby)
+
+		if recorder := app.openRecorder(baby.UID); recorder != nil {
+			ws.Recorder = recorder
+			defer recorder.Close()
+		}
+
+		app.websocketsMu.Lock()
+		app.websockets[baby.UID] = ws
+		app.websocketsMu.Unlock()
+
+		// Handlers must be in place before the first message arrives, the cam starts pushing right after connecting
+		ws.WithConnectionSetup(func(conn *client.WebsocketConnection) {
+			conn.RegisterMessageHandler(app.handleWebsocketMessage(baby.UID))
+			app.registerRequestHandlers(baby.UID, conn)
+		})
 
 		ws.WithReadyConnection(func(conn *client.WebsocketConnection, childCtx utils.GracefulContext) {
 			app.runWebsocket(baby.UID, conn, childCtx)
@@ -101,8 +172,51 @@ func (app *App) handleBaby(baby baby.Baby, ctx utils.GracefulContext) {
 	<-ctx.Done()
 }
 
+// newWebsocketManager - creates connection manager for a baby's cam according to the options
+func (app *App) newWebsocketManager(babyInfo baby.Baby) *client.WebsocketConnectionManager {
+	ws := client.NewWebsocketConnectionManager(babyInfo.UID, babyInfo.CameraUID, app.RestClient, app.BabyStateManager)
+	ws.Transport = client.NewTransport(app.Opts.Websocket)
+	ws.LocalAddr = app.Opts.LocalCamAddrs[babyInfo.UID]
+	ws.KeepaliveTimeout = app.Opts.KeepaliveTimeout
+	ws.Inspector = app.inspector
+
+	if fingerprint := app.Opts.LocalCamCertFingerprints[babyInfo.UID]; fingerprint != "" || app.Opts.LocalCamSkipTLSVerify {
+		tlsConfig, err := client.LocalCamTLSConfig(fingerprint)
+		if err != nil {
+			log.Fatal().Str("baby_uid", babyInfo.UID).Err(err).Msg("Invalid local cam certificate fingerprint")
+		}
+
+		localOpts := app.Opts.Websocket
+		localOpts.TLSConfig = tlsConfig
+		ws.LocalTransport = client.NewTransport(localOpts)
+	}
+
+	return ws
+}
+
+// getReadyConnection - returns ready websocket connection of a baby's cam
+func (app *App) getReadyConnection(babyUID string) (*client.WebsocketConnection, error) {
+	app.websocketsMu.RLock()
+	ws, ok := app.websockets[babyUID]
+	app.websocketsMu.RUnlock()
+
+	if !ok {
+		return nil, errWebsocketNotReady
+	}
+
+	conn := ws.GetReadyConnection()
+	if conn == nil {
+		return nil, errWebsocketNotReady
+	}
+
+	return conn, nil
+}
+
 func (app *App) pollMessages(babyUID string, babyStateManager *baby.StateManager) {
-	newMessages := app.RestClient.FetchNewMessages(babyUID, app.Opts.EventPolling.MessageTimeout)
+	newMessages, err := app.RestClient.FetchNewMessages(babyUID, app.Opts.EventPolling.MessageTimeout)
+	if err != nil {
+		log.Warn().Str("baby_uid", babyUID).Err(err).Msg("Unable to poll messages")
+	}
 
 	for _, msg := range newMessages {
 		switch msg.Type {
@@ -121,51 +235,75 @@ func (app *App) pollMessages(babyUID string, babyStateManager *baby.StateManager
 }
 
 func (app *App) runWebsocket(babyUID string, conn *client.WebsocketConnection, childCtx utils.GracefulContext) {
-	// Reading sensor data
-	conn.RegisterMessageHandler(func(m *client.Message, conn *client.WebsocketConnection) {
-		// Sensor request initiated by us on start (or some other client, we don't care)
-		if *m.Type == client.Message_RESPONSE && m.Response != nil {
-			if *m.Response.RequestType == client.RequestType_GET_SENSOR_DATA && len(m.Response.SensorData) > 0 {
-				processSensorData(babyUID, m.Response.SensorData, app.BabyStateManager)
-			}
-		} else
-
-		// Communication initiated from a cam
-		// Note: it sends the updates periodically on its own + whenever some significant change occurs
-		if *m.Type == client.Message_REQUEST && m.Request != nil {
-			if *m.Request.Type == client.RequestType_PUT_SENSOR_DATA && len(m.Request.SensorData_) > 0 {
-				processSensorData(babyUID, m.Request.SensorData_, app.BabyStateManager)
-			}
-		}
-	})
+	// Choose which sensor data the cam pushes (applied on every connection as the cam does not remember it)
+	go app.applySensorDataTransfer(babyUID, conn)
 
 	// Ask for sensor data (initial request)
-	conn.SendRequest(client.RequestType_GET_SENSOR_DATA, &client.Request{
+	conn.Send(client.RequestType_GET_SENSOR_DATA, &client.Request{
 		GetSensorData: &client.GetSensorData{
 			All: utils.ConstRefBool(true),
 		},
 	})
 
-	// Ask for status
-	// conn.SendRequest(client.RequestType_GET_STATUS, &client.Request{
-	// 	GetStatus_: &client.GetStatus{
-	// 		All: utils.ConstRefBool(true),
-	// 	},
-	// })
+	// Ask for settings
+	if profile, ok := app.Opts.BabyStreamProfiles[babyUID]; ok {
+		// Apply configured stream profile once we know the current stream settings
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			settings, err := conn.GetSettings(ctx)
+			if err != nil {
+				log.Error().Str("baby_uid", babyUID).Err(err).Msg("Unable to retrieve settings, stream profile not applied")
+				return
+			}
 
-	// Ask for logs
-	// conn.SendRequest(client.RequestType_GET_LOGS, &client.Request{
-	// 	GetLogs: &client.GetLogs{
-	// 		Url: utils.ConstRefStr("http://192.168.3.234:8080/log"),
-	// 	},
-	// })
+			// Profile is resolved against the stored settings, don't rely on the message handler having stored them already
+			app.storeSettings(babyUID, settings)
+
+			if err := app.SetStreamProfile(babyUID, profile); err != nil {
+				log.Error().Str("baby_uid", babyUID).Err(err).Msg("Unable to apply stream profile")
+			}
+		}()
+	} else {
+		conn.Send(client.RequestType_GET_SETTINGS, &client.Request{})
+	}
+
+	// Ask for status (initial request + periodic polling)
+	requestStatus(conn)
+
+	if app.Opts.StatusPollingInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(app.Opts.StatusPollingInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-childCtx.Done():
+					return
+				case <-ticker.C:
+					requestStatus(conn)
+				}
+			}
+		}()
+	}
+
+	// Network monitoring
+	if app.Opts.NetworkPollingInterval > 0 {
+		go app.pollNetwork(babyUID, conn, childCtx.Done())
+	}
+
+	// Additional stream targets (ie. recorder)
+	if len(app.Opts.StreamTargets) > 0 {
+		app.runStreamTargets(babyUID, conn, childCtx)
+	}
 
 	var cleanup func()
 
 	// Local streaming
 	if app.Opts.RTMP != nil {
 		initializeLocalStreaming := func() {
-			requestLocalStreaming(babyUID, app.getLocalStreamURL(babyUID), client.Streaming_STARTED, conn, app.BabyStateManager)
+			requestLocalStreaming(babyUID, app.getLocalStreamURL(babyUID), app.getLocalStreamIdentifier(), client.Streaming_STARTED, conn, app.BabyStateManager)
 		}
 
 		// Watch for stream liveness change
@@ -186,7 +324,7 @@ func (app *App) runWebsocket(babyUID string, conn *client.WebsocketConnection, c
 			// Stop local streaming
 			state := app.BabyStateManager.GetBabyState(babyUID)
 			if state.GetIsWebsocketAlive() && state.GetStreamState() == baby.StreamState_Alive {
-				requestLocalStreaming(babyUID, app.getLocalStreamURL(babyUID), client.Streaming_STOPPED, conn, app.BabyStateManager)
+				requestLocalStreaming(babyUID, app.getLocalStreamURL(babyUID), app.getLocalStreamIdentifier(), client.Streaming_STOPPED, conn, app.BabyStateManager)
 			}
 		}
 
@@ -205,8 +343,79 @@ func (app *App) runWebsocket(babyUID string, conn *client.WebsocketConnection, c
 	}
 }
 
+// handleWebsocketMessage - returns handler processing responses from the baby's cam
+func (app *App) handleWebsocketMessage(babyUID string) client.WebsocketMessageHandler {
+	return func(m *client.Message, _ *client.WebsocketConnection) {
+		// Sensor request initiated by us on start (or some other client, we don't care)
+		if *m.Type == client.Message_RESPONSE && m.Response != nil {
+			if *m.Response.RequestType == client.RequestType_GET_SENSOR_DATA && len(m.Response.SensorData) > 0 {
+				processSensorData(babyUID, m.Response.SensorData, app.BabyStateManager)
+			} else if *m.Response.RequestType == client.RequestType_GET_SETTINGS && m.Response.Settings != nil {
+				app.storeSettings(babyUID, m.Response.Settings)
+			} else if *m.Response.RequestType == client.RequestType_GET_STATUS && m.Response.Status != nil {
+				app.handleStatus(babyUID, m.Response.Status)
+			}
+		}
+	}
+}
+
+// registerRequestHandlers - registers handlers of the communication initiated from the baby's cam
+// Note: it sends the updates periodically on its own + whenever some significant change occurs
+func (app *App) registerRequestHandlers(babyUID string, conn *client.WebsocketConnection) {
+	conn.HandleRequest(client.RequestType_PUT_SENSOR_DATA, func(r *client.Request, _ *client.WebsocketConnection) *client.Response {
+		if len(r.SensorData_) > 0 {
+			processSensorData(babyUID, r.SensorData_, app.BabyStateManager)
+		}
+
+		return nil
+	})
+
+	conn.HandleRequest(client.RequestType_PUT_CONTROL, func(r *client.Request, _ *client.WebsocketConnection) *client.Response {
+		if r.Control != nil {
+			processControl(babyUID, r.Control, app.BabyStateManager)
+		}
+
+		return nil
+	})
+
+	conn.HandleRequest(client.RequestType_PUT_SETTINGS, func(r *client.Request, _ *client.WebsocketConnection) *client.Response {
+		if r.Settings != nil {
+			app.storeSettings(babyUID, r.Settings)
+		}
+
+		return nil
+	})
+
+	conn.HandleRequest(client.RequestType_PUT_STATUS, func(r *client.Request, _ *client.WebsocketConnection) *client.Response {
+		if r.Status != nil {
+			app.handleStatus(babyUID, r.Status)
+		}
+
+		return nil
+	})
+
+	conn.HandleRequest(client.RequestType_PUT_PLAYBACK, func(r *client.Request, _ *client.WebsocketConnection) *client.Response {
+		if r.Playback != nil {
+			processPlayback(babyUID, r.Playback, app.BabyStateManager)
+		}
+
+		return nil
+	})
+
+	conn.HandleRequest(client.RequestType_PUT_FIRMWARE, func(_ *client.Request, conn *client.WebsocketConnection) *client.Response {
+		// Firmware payload is not known, ask for status which contains the versions
+		go requestStatus(conn)
+		return nil
+	})
+
+	// Cam's own liveness check, nothing to process
+	conn.HandleRequest(client.RequestType_PUT_KEEP_ALIVE, func(_ *client.Request, _ *client.WebsocketConnection) *client.Response {
+		return nil
+	})
+}
+
 func (app *App) getRemoteStreamURL(babyUID string) string {
-	return fmt.Sprintf("rtmps://media-secured.nanit.com/nanit/%v.%v", babyUID, app.SessionStore.Session.AuthToken)
+	return fmt.Sprintf("rtmps://media-secured.nanit.com/nanit/%v.%v", babyUID, app.RestClient.GetAuthToken())
 }
 
 func (app *App) getLocalStreamURL(babyUID string) string {
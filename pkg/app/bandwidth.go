@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+// Bandwidth - raw result of GET_BANDWIDTH request, kept for reverse engineering (see docs/developer-notes.md#requests-with-unknown-payload)
+type Bandwidth struct {
+	Time   time.Time           `json:"time"`
+	Fields client.RawBandwidth `json:"fields"`
+}
+
+// CheckBandwidth - asks the cam for bandwidth info and remembers the result
+func (app *App) CheckBandwidth(babyUID string) (*Bandwidth, error) {
+	conn, err := app.getReadyConnection(babyUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.requestBandwidth(babyUID, conn)
+}
+
+// GetLastBandwidth - returns last bandwidth check result or nil
+func (app *App) GetLastBandwidth(babyUID string) *Bandwidth {
+	app.bandwidthMu.RLock()
+	defer app.bandwidthMu.RUnlock()
+
+	return app.bandwidth[babyUID]
+}
+
+func (app *App) requestBandwidth(babyUID string, conn *client.WebsocketConnection) (*Bandwidth, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	fields, err := conn.GetBandwidth(ctx)
+	if err != nil {
+		log.Warn().Str("baby_uid", babyUID).Err(err).Msg("Bandwidth check failed")
+		return nil, err
+	}
+
+	bandwidth := &Bandwidth{
+		Time:   start,
+		Fields: fields,
+	}
+
+	log.Debug().Str("baby_uid", babyUID).Interface("fields", bandwidth.Fields).Msg("Bandwidth checked")
+
+	app.bandwidthMu.Lock()
+	app.bandwidth[babyUID] = bandwidth
+	app.bandwidthMu.Unlock()
+
+	return bandwidth, nil
+}
+
+func (app *App) handleBandwidthRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	switch r.Method {
+	case http.MethodGet:
+		bandwidth := app.GetLastBandwidth(babyUID)
+		if bandwidth == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(w, bandwidth)
+
+	case http.MethodPost:
+		bandwidth, err := app.CheckBandwidth(babyUID)
+		if err != nil {
+			writeCamError(w, err)
+			return
+		}
+
+		writeJSON(w, bandwidth)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,284 @@
+package app
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+var errCamLogsTimeout = errors.New("Cam did not upload the logs in time")
+var errHTTPAddrMissing = errors.New("Public HTTP address (NANIT_HTTP_ADDR) has to be set for the cam to be able to upload logs")
+
+var camLogsURLRX = regexp.MustCompile(`^/log(?:/([A-Za-z0-9_-]+))?$`)
+
+// camLogsWaiters - channels awaiting log upload, keyed by camera UID
+type camLogsWaiters struct {
+	mu      sync.Mutex
+	waiters map[string][]chan string
+}
+
+func (w *camLogsWaiters) add(cameraUID string) chan string {
+	c := make(chan string, 1)
+
+	w.mu.Lock()
+	if w.waiters == nil {
+		w.waiters = make(map[string][]chan string)
+	}
+	w.waiters[cameraUID] = append(w.waiters[cameraUID], c)
+	w.mu.Unlock()
+
+	return c
+}
+
+func (w *camLogsWaiters) remove(cameraUID string, c chan string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, waiter := range w.waiters[cameraUID] {
+		if waiter == c {
+			w.waiters[cameraUID] = append(w.waiters[cameraUID][:i], w.waiters[cameraUID][i+1:]...)
+			return
+		}
+	}
+}
+
+func (w *camLogsWaiters) notify(cameraUID string, dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, c := range w.waiters[cameraUID] {
+		select {
+		case c <- dir:
+		default:
+		}
+	}
+}
+
+// CollectCamLogs - asks the cam for its logs and waits until they are uploaded and extracted
+// Starts the log receiver unless the HTTP server is already running. Returns directory with extracted logs.
+// Timeout includes establishing the connection to the cam.
+func (app *App) CollectCamLogs(babyUIDOrName string, timeout time.Duration) (string, error) {
+	if app.Opts.HTTPPublicAddr == "" {
+		return "", errHTTPAddrMissing
+	}
+
+	if !app.Opts.HTTPEnabled {
+		listenErrC := make(chan error, 1)
+		go func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/log", app.handleCamLogsUpload)
+			mux.HandleFunc("/log/", app.handleCamLogsUpload)
+
+			log.Info().Int("port", httpPort).Msg("Starting cam log receiver")
+			listenErrC <- http.ListenAndServe(fmt.Sprintf(":%v", httpPort), mux)
+		}()
+
+		// Give the receiver a moment to fail if the port is taken
+		select {
+		case err := <-listenErrC:
+			return "", fmt.Errorf("Unable to start cam log receiver: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var dir string
+	err := app.RunCommand(ctx, babyUIDOrName, func(ctx context.Context, babyUID string, conn *client.WebsocketConnection) error {
+		var err error
+		dir, err = app.requestCamLogs(ctx, babyUID, conn)
+		return err
+	})
+
+	return dir, err
+}
+
+// requestCamLogs - sends GET_LOGS request with the callback URL and waits for the upload until the context is done
+func (app *App) requestCamLogs(ctx context.Context, babyUID string, conn *client.WebsocketConnection) (string, error) {
+	if app.Opts.HTTPPublicAddr == "" {
+		return "", errHTTPAddrMissing
+	}
+
+	cameraUID := app.getCameraUID(babyUID)
+	url := fmt.Sprintf("http://%v/log/%v", app.Opts.HTTPPublicAddr, cameraUID)
+
+	uploadC := app.camLogsWaiters.add(cameraUID)
+	defer app.camLogsWaiters.remove(cameraUID, uploadC)
+
+	log.Info().Str("baby_uid", babyUID).Str("url", url).Msg("Requesting cam logs")
+
+	reqCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := conn.GetLogs(reqCtx, url); err != nil {
+		return "", err
+	}
+
+	log.Info().Msg("Cam accepted the request, waiting for the logs to be uploaded (this can take several minutes)")
+
+	select {
+	case dir := <-uploadC:
+		return dir, nil
+	case <-ctx.Done():
+		return "", errCamLogsTimeout
+	}
+}
+
+func (app *App) handleCamLogsRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	conn, err := app.getReadyConnection(babyUID)
+	if err != nil {
+		writeCamError(w, err)
+		return
+	}
+
+	if app.Opts.HTTPPublicAddr == "" {
+		writeError(w, http.StatusServiceUnavailable, errHTTPAddrMissing)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+		defer cancel()
+
+		dir, err := app.requestCamLogs(ctx, babyUID, conn)
+		if err != nil {
+			log.Error().Str("baby_uid", babyUID).Err(err).Msg("Unable to collect cam logs")
+		} else {
+			log.Info().Str("baby_uid", babyUID).Str("dir", dir).Msg("Cam logs collected")
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleCamLogsUpload - receives logs from the cam
+// Note: Cam is sending tared archive through curl as binary file
+func (app *App) handleCamLogsUpload(w http.ResponseWriter, r *http.Request) {
+	submatch := camLogsURLRX.FindStringSubmatch(r.URL.Path)
+	if submatch == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	defer r.Body.Close()
+
+	cameraUID := submatch[1]
+	dir := filepath.Join(app.Opts.DataDirectories.LogDir, cameraUID, time.Now().Format("20060102-150405"))
+	filename := filepath.Join(dir, "camlogs.tar.gz")
+
+	log.Info().Str("file", filename).Msg("Saving log to file")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Error().Str("dir", dir).Err(err).Msg("Unable to create directory")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := saveFile(filename, r.Body); err != nil {
+		log.Error().Str("file", filename).Err(err).Msg("Unable to save received log file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	if err := extractTarGz(filename, dir); err != nil {
+		log.Warn().Str("file", filename).Err(err).Msg("Unable to extract received log file, keeping the archive only")
+	}
+
+	if cameraUID != "" {
+		app.camLogsWaiters.notify(cameraUID, dir)
+	}
+}
+
+func (app *App) getCameraUID(babyUID string) string {
+	for _, babyInfo := range app.RestClient.GetBabies() {
+		if babyInfo.UID == babyUID {
+			return babyInfo.CameraUID
+		}
+	}
+
+	return ""
+}
+
+func saveFile(filename string, r io.Reader) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	_, err = io.Copy(out, r)
+	return err
+}
+
+// extractTarGz - extracts regular files and directories of the archive, skipping anything pointing outside of dir
+func extractTarGz(filename string, dir string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, header.Name)
+		if target == filepath.Clean(dir) {
+			continue
+		} else if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			log.Warn().Str("name", header.Name).Msg("Skipping archive entry outside of target directory")
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+
+			if err := saveFile(target, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
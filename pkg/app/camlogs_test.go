@@ -0,0 +1,87 @@
+package app
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestTarGz(t *testing.T, filename string, files map[string]string) {
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func TestExtractTarGz(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "nanit-camlogs")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	archive := filepath.Join(tmp, "logs.tar.gz")
+	writeTestTarGz(t, archive, map[string]string{
+		"messages.log":        "hello",
+		"./nested/app.log":    "nested",
+		"../outside.log":      "escaped",
+		"nested/../../up.log": "escaped",
+		"/absolute.log":       "absolute",
+	})
+
+	dir := filepath.Join(tmp, "out")
+	require.NoError(t, extractTarGz(archive, dir))
+
+	cases := []struct {
+		path    string
+		content string
+	}{
+		{"out/messages.log", "hello"},
+		{"out/nested/app.log", "nested"},
+		{"out/absolute.log", "absolute"}, // joined under the target directory
+	}
+
+	for _, c := range cases {
+		data, err := ioutil.ReadFile(filepath.Join(tmp, c.path))
+		if assert.NoError(t, err, c.path) {
+			assert.Equal(t, c.content, string(data), c.path)
+		}
+	}
+
+	for _, path := range []string{"outside.log", "up.log"} {
+		assert.NoFileExists(t, filepath.Join(tmp, path), "Should not extract entries outside of target directory")
+	}
+}
+
+func TestExtractTarGzInvalid(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "nanit-camlogs")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	archive := filepath.Join(tmp, "logs.tar.gz")
+	require.NoError(t, ioutil.WriteFile(archive, []byte("not an archive"), 0644))
+
+	assert.Error(t, extractTarGz(archive, filepath.Join(tmp, "out")))
+	assert.Error(t, extractTarGz(filepath.Join(tmp, "missing.tar.gz"), filepath.Join(tmp, "out")))
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gregory-m/nanit/pkg/baby"
+	"github.com/gregory-m/nanit/pkg/client"
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+// CommandHandler - one-off command executed on a ready websocket connection, should return once the context is done
+type CommandHandler func(ctx context.Context, babyUID string, conn *client.WebsocketConnection) error
+
+var errCamNotConnected = errors.New("Connection to the cam was not established in time")
+var errCommandTimeout = errors.New("Command did not finish in time")
+
+// RunCommand - connects to the cam of a single baby (by UID or name) and runs the command once the connection is ready
+// Context bounds both waiting for the connection and the command itself.
+// Used by CLI subcommands which don't run the whole application loop
+func (app *App) RunCommand(ctx context.Context, babyUIDOrName string, command CommandHandler) error {
+	app.authorize()
+
+	babyInfo, err := app.findBaby(babyUIDOrName)
+	if err != nil {
+		return err
+	}
+
+	ws := app.newWebsocketManager(babyInfo)
+
+	app.websocketsMu.Lock()
+	app.websockets[babyInfo.UID] = ws
+	app.websocketsMu.Unlock()
+
+	var once sync.Once
+	startedC := make(chan struct{})
+	resultC := make(chan error, 1)
+
+	ws.WithReadyConnection(func(conn *client.WebsocketConnection, _ utils.GracefulContext) {
+		once.Do(func() {
+			close(startedC)
+			resultC <- command(ctx, babyInfo.UID, conn)
+		})
+	})
+
+	runner := utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		ws.RunWithinContext(ctx)
+	})
+
+	defer runner.Cancel()
+
+	select {
+	case err := <-resultC:
+		return err
+	case <-ctx.Done():
+		select {
+		case <-startedC:
+			return errCommandTimeout
+		default:
+			return errCamNotConnected
+		}
+	}
+}
+
+// findBaby - looks up baby by UID or (case insensitive) name
+func (app *App) findBaby(babyUIDOrName string) (baby.Baby, error) {
+	babies, err := app.RestClient.EnsureBabies()
+	if err != nil {
+		return baby.Baby{}, err
+	}
+
+	for _, babyInfo := range babies {
+		if babyInfo.UID == babyUIDOrName || strings.EqualFold(babyInfo.Name, babyUIDOrName) {
+			return babyInfo, nil
+		}
+	}
+
+	return baby.Baby{}, fmt.Errorf("Unknown baby %q", babyUIDOrName)
+}
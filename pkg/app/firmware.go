@@ -0,0 +1,89 @@
+package app
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/baby"
+	"github.com/gregory-m/nanit/pkg/client"
+	"github.com/gregory-m/nanit/pkg/session"
+)
+
+const (
+	// FirmwareUpgradedEvent - cam is running different firmware version than last time we saw it
+	FirmwareUpgradedEvent = "firmware_upgraded"
+
+	// FirmwareUpgradePendingEvent - cam has downloaded firmware upgrade which is waiting to be installed
+	FirmwareUpgradePendingEvent = "firmware_upgrade_pending"
+)
+
+// handleStatus - processes status received from the cam
+func (app *App) handleStatus(babyUID string, status *client.Status) {
+	processStatus(babyUID, status, app.BabyStateManager)
+	app.trackFirmware(babyUID, status)
+}
+
+// trackFirmware - compares reported firmware with the one persisted in the session and emits events on change
+func (app *App) trackFirmware(babyUID string, status *client.Status) {
+	cameraUID := app.getCameraUID(babyUID)
+
+	app.firmwareMu.Lock()
+	defer app.firmwareMu.Unlock()
+
+	var prev session.FirmwareInfo
+	var known bool
+
+	app.SessionStore.View(func(s *session.Session) {
+		prev, known = s.Firmware[cameraUID]
+	})
+
+	curr := prev
+
+	if status.CurrentVersion != nil {
+		curr.CurrentVersion = status.GetCurrentVersion()
+	}
+
+	if status.DownloadedVersion != nil {
+		curr.DownloadedVersion = status.GetDownloadedVersion()
+	}
+
+	if status.UpgradeDownloaded != nil {
+		curr.UpgradeDownloaded = status.GetUpgradeDownloaded()
+	}
+
+	if status.IsSecurityUpgrade != nil {
+		curr.IsSecurityUpgrade = status.GetIsSecurityUpgrade()
+	}
+
+	if known && curr == prev {
+		return
+	}
+
+	now := time.Now()
+
+	if known && prev.CurrentVersion != "" && curr.CurrentVersion != prev.CurrentVersion {
+		log.Info().Str("baby_uid", babyUID).Str("previous_version", prev.CurrentVersion).Str("current_version", curr.CurrentVersion).Msg("Cam firmware upgraded")
+		app.BabyStateManager.NotifyEvent(babyUID, *baby.NewEvent(FirmwareUpgradedEvent, now).
+			With("previous_version", prev.CurrentVersion).
+			With("current_version", curr.CurrentVersion))
+	}
+
+	if curr.UpgradeDownloaded && (!prev.UpgradeDownloaded || curr.DownloadedVersion != prev.DownloadedVersion) {
+		log.Info().Str("baby_uid", babyUID).Str("downloaded_version", curr.DownloadedVersion).Msg("Cam firmware upgrade pending")
+		app.BabyStateManager.NotifyEvent(babyUID, *baby.NewEvent(FirmwareUpgradePendingEvent, now).
+			With("current_version", curr.CurrentVersion).
+			With("downloaded_version", curr.DownloadedVersion).
+			With("is_security_upgrade", curr.IsSecurityUpgrade))
+	}
+
+	log.Debug().Str("camera_uid", cameraUID).Str("version", curr.CurrentVersion).Msg("Storing cam firmware info")
+
+	app.SessionStore.Update(func(s *session.Session) {
+		if s.Firmware == nil {
+			s.Firmware = make(map[string]session.FirmwareInfo)
+		}
+
+		s.Firmware[cameraUID] = curr
+	})
+}
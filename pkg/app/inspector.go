@@ -0,0 +1,34 @@
+package app
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+// openInspector - opens inspector of received messages, returns nil if inspection is disabled
+func openInspector(filename string) *client.Inspector {
+	if filename == "" {
+		return nil
+	}
+
+	inspector, err := client.OpenInspector(filename)
+	if err != nil {
+		log.Error().Str("file", filename).Err(err).Msg("Unable to open inspector report")
+		return nil
+	}
+
+	log.Info().Str("file", filename).Msg("Inspecting received messages for unknown fields")
+	return inspector
+}
+
+// flushInspector - writes current occurrence counts to the inspector report
+func (app *App) flushInspector() {
+	if app.inspector == nil {
+		return
+	}
+
+	if err := app.inspector.Flush(); err != nil {
+		log.Error().Err(err).Msg("Unable to write inspector report")
+	}
+}
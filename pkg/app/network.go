@@ -0,0 +1,199 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/baby"
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+// NetworkStatusEvent - periodic report of cam network status
+const NetworkStatusEvent = "network_status"
+
+// NetworkDiagnostics - result of GET_STATUS_NETWORK and GET_LIST_NETWORKS requests
+// Field numbers of the payloads are not confirmed (see docs/developer-notes.md#requests-with-unknown-payload), so the networks
+// are only guessed from the raw fields to help with reverse engineering and are not published as state
+type NetworkDiagnostics struct {
+	Time            time.Time               `json:"time"`
+	RawStatus       client.RawNetworkStatus `json:"raw_status"`
+	RawNetworks     client.RawNetworkList   `json:"raw_networks"`
+	GuessedCurrent  *WifiNetwork            `json:"guessed_current,omitempty"`
+	GuessedNetworks []WifiNetwork           `json:"guessed_networks"`
+}
+
+// WifiNetwork - wifi network guessed from the raw fields
+type WifiNetwork struct {
+	SSID          string `json:"ssid,omitempty"`
+	RSSI          int32  `json:"rssi,omitempty"`           // dBm
+	SignalQuality int32  `json:"signal_quality,omitempty"` // percent derived from RSSI
+	Band          string `json:"band,omitempty"`           // 2.4GHz / 5GHz derived from channel frequency
+}
+
+// decodeWifiNetwork - guesses network from fields of a single raw message
+// First printable string is taken as SSID, negative int32 varint in the dBm range as RSSI and varint in the wifi frequency ranges (MHz) as band.
+// Fixed width numbers and bytes are ignored. Returns false if neither SSID nor RSSI is found.
+func decodeWifiNetwork(fields []client.RawField) (WifiNetwork, bool) {
+	network := WifiNetwork{}
+	hasRSSI := false
+
+	for _, field := range fields {
+		switch value := field.Value.(type) {
+		case string:
+			if field.Type == "string" && network.SSID == "" {
+				network.SSID = value
+			}
+
+		case uint64:
+			if field.Type != "varint" {
+				continue
+			}
+
+			if signed := int64(value); !hasRSSI && signed >= -120 && signed < 0 {
+				network.RSSI = int32(signed)
+				network.SignalQuality = rssiToQuality(network.RSSI)
+				hasRSSI = true
+			} else if value >= 2400 && value <= 2500 {
+				network.Band = "2.4GHz"
+			} else if value >= 4900 && value <= 5900 {
+				network.Band = "5GHz"
+			}
+		}
+	}
+
+	return network, network.SSID != "" || hasRSSI
+}
+
+// decodeWifiNetworks - decodes all networks found in nested messages of the raw fields
+func decodeWifiNetworks(fields []client.RawField) []WifiNetwork {
+	networks := make([]WifiNetwork, 0)
+
+	for _, field := range fields {
+		if nested, ok := field.Value.([]client.RawField); ok {
+			if network, ok := decodeWifiNetwork(nested); ok {
+				networks = append(networks, network)
+			} else {
+				networks = append(networks, decodeWifiNetworks(nested)...)
+			}
+		}
+	}
+
+	return networks
+}
+
+// decodeCurrentWifiNetwork - decodes network the cam is connected to from the status, either from top level fields or the first nested message
+func decodeCurrentWifiNetwork(fields []client.RawField) *WifiNetwork {
+	if network, ok := decodeWifiNetwork(fields); ok {
+		return &network
+	}
+
+	if networks := decodeWifiNetworks(fields); len(networks) > 0 {
+		return &networks[0]
+	}
+
+	return nil
+}
+
+// rssiToQuality - converts dBm to percent (-100 dBm and below = 0 %, -50 dBm and above = 100 %)
+func rssiToQuality(rssi int32) int32 {
+	quality := 2 * (rssi + 100)
+	if quality < 0 {
+		return 0
+	} else if quality > 100 {
+		return 100
+	}
+
+	return quality
+}
+
+// CheckNetwork - asks the cam for its network status and visible networks
+func (app *App) CheckNetwork(babyUID string) (*NetworkDiagnostics, error) {
+	conn, err := app.getReadyConnection(babyUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return requestNetworkDiagnostics(babyUID, conn)
+}
+
+func requestNetworkDiagnostics(babyUID string, conn *client.WebsocketConnection) (*NetworkDiagnostics, error) {
+	diag := &NetworkDiagnostics{Time: time.Now()}
+
+	// Scanning for networks takes a while and is not essential, run it alongside
+	type networksResult struct {
+		networks client.RawNetworkList
+		err      error
+	}
+
+	networksC := make(chan networksResult, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		networks, err := conn.GetListNetworks(ctx)
+		networksC <- networksResult{networks, err}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	status, err := conn.GetStatusNetwork(ctx)
+	if err != nil {
+		log.Warn().Str("baby_uid", babyUID).Err(err).Msg("Network status check failed")
+		return nil, err
+	}
+
+	diag.RawStatus = status
+	diag.GuessedCurrent = decodeCurrentWifiNetwork(diag.RawStatus)
+
+	if networks := <-networksC; networks.err != nil {
+		log.Warn().Str("baby_uid", babyUID).Err(networks.err).Msg("Unable to list networks visible by the cam")
+		diag.GuessedNetworks = make([]WifiNetwork, 0)
+	} else {
+		diag.RawNetworks = networks.networks
+		diag.GuessedNetworks = decodeWifiNetworks(diag.RawNetworks)
+	}
+
+	log.Debug().Str("baby_uid", babyUID).Interface("raw_status", diag.RawStatus).Interface("raw_networks", diag.RawNetworks).Interface("guessed_current", diag.GuessedCurrent).Msg("Network checked")
+	return diag, nil
+}
+
+// pollNetwork - periodically checks the cam network and publishes the result as an event
+func (app *App) pollNetwork(babyUID string, conn *client.WebsocketConnection, ctx <-chan struct{}) {
+	ticker := time.NewTicker(app.Opts.NetworkPollingInterval)
+	defer ticker.Stop()
+
+	for {
+		if diag, err := requestNetworkDiagnostics(babyUID, conn); err == nil {
+			app.BabyStateManager.NotifyEvent(babyUID, *baby.NewEvent(NetworkStatusEvent, diag.Time).
+				With("raw_status", diag.RawStatus).
+				With("raw_networks", diag.RawNetworks).
+				With("guessed_current", diag.GuessedCurrent).
+				With("guessed_networks", diag.GuessedNetworks))
+		}
+
+		select {
+		case <-ctx:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (app *App) handleNetworkRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	diag, err := app.CheckNetwork(babyUID)
+	if err != nil {
+		writeCamError(w, err)
+		return
+	}
+
+	writeJSON(w, diag)
+}
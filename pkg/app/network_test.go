@@ -0,0 +1,116 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+func negativeVarint(value int64) uint64 {
+	return uint64(value)
+}
+
+func TestDecodeWifiNetwork(t *testing.T) {
+	cases := []struct {
+		name    string
+		fields  []client.RawField
+		network WifiNetwork
+		found   bool
+	}{
+		{
+			"full",
+			[]client.RawField{
+				{Number: 1, Type: "string", Value: "Home"},
+				{Number: 2, Type: "varint", Value: negativeVarint(-60)},
+				{Number: 3, Type: "varint", Value: uint64(2437)},
+			},
+			WifiNetwork{SSID: "Home", RSSI: -60, SignalQuality: 80, Band: "2.4GHz"},
+			true,
+		},
+		{
+			"5GHz without SSID",
+			[]client.RawField{
+				{Number: 2, Type: "varint", Value: negativeVarint(-75)},
+				{Number: 3, Type: "varint", Value: uint64(5180)},
+			},
+			WifiNetwork{RSSI: -75, SignalQuality: 50, Band: "5GHz"},
+			true,
+		},
+		{
+			"first string and RSSI win",
+			[]client.RawField{
+				{Number: 1, Type: "string", Value: "First"},
+				{Number: 2, Type: "string", Value: "Second"},
+				{Number: 3, Type: "varint", Value: negativeVarint(-40)},
+				{Number: 4, Type: "varint", Value: negativeVarint(-90)},
+			},
+			WifiNetwork{SSID: "First", RSSI: -40, SignalQuality: 100},
+			true,
+		},
+		{
+			"fixed width numbers ignored",
+			[]client.RawField{
+				{Number: 1, Type: "fixed64", Value: negativeVarint(-60)},
+				{Number: 2, Type: "fixed32", Value: uint64(2437)},
+			},
+			WifiNetwork{},
+			false,
+		},
+		{
+			"bytes ignored",
+			[]client.RawField{
+				{Number: 1, Type: "bytes", Value: []byte{0x00, 0x01}},
+				{Number: 2, Type: "varint", Value: uint64(1)},
+			},
+			WifiNetwork{},
+			false,
+		},
+		{
+			"empty",
+			nil,
+			WifiNetwork{},
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		network, found := decodeWifiNetwork(c.fields)
+		assert.Equal(t, c.found, found, c.name)
+		assert.Equal(t, c.network, network, c.name)
+	}
+}
+
+func TestDecodeWifiNetworksNested(t *testing.T) {
+	fields := []client.RawField{
+		{Number: 1, Type: "message", Value: []client.RawField{
+			{Number: 1, Type: "string", Value: "Home"},
+		}},
+		{Number: 2, Type: "message", Value: []client.RawField{
+			{Number: 1, Type: "message", Value: []client.RawField{
+				{Number: 1, Type: "string", Value: "Neighbour"},
+			}},
+		}},
+		{Number: 3, Type: "varint", Value: uint64(1)},
+	}
+
+	assert.Equal(t, []WifiNetwork{{SSID: "Home"}, {SSID: "Neighbour"}}, decodeWifiNetworks(fields))
+	assert.Equal(t, &WifiNetwork{SSID: "Home"}, decodeCurrentWifiNetwork(fields))
+	assert.Nil(t, decodeCurrentWifiNetwork(nil))
+}
+
+func TestRSSIToQuality(t *testing.T) {
+	cases := map[int32]int32{
+		-120: 0,
+		-100: 0,
+		-99:  2,
+		-75:  50,
+		-50:  100,
+		-30:  100,
+	}
+
+	for rssi, quality := range cases {
+		assert.Equal(t, quality, rssiToQuality(rssi), "RSSI %v", rssi)
+	}
+}
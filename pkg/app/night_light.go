@@ -0,0 +1,120 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/baby"
+)
+
+// nightLightCommand - JSON form of the night light command, ie. {"state": "ON", "timeout": 600}
+type nightLightCommand struct {
+	State   string `json:"state"`
+	Timeout int32  `json:"timeout"` // seconds
+}
+
+// SetNightLight - turns night light on / off
+// If timeout is non-zero, the cam turns the light off on its own after given duration
+func (app *App) SetNightLight(babyUID string, on bool, timeout time.Duration) error {
+	conn, err := app.getReadyConnection(babyUID)
+	if err != nil {
+		return err
+	}
+
+	err = requestNightLight(babyUID, on, timeout, conn, app.BabyStateManager)
+	if err != nil {
+		return err
+	}
+
+	// Reflect the automatic turn off in the state, we won't hear about it from the cam
+	app.nightLightTimersMu.Lock()
+	if timer, ok := app.nightLightTimers[babyUID]; ok {
+		timer.Stop()
+		delete(app.nightLightTimers, babyUID)
+	}
+
+	if on && timeout > 0 {
+		app.nightLightTimers[babyUID] = time.AfterFunc(timeout, func() {
+			app.BabyStateManager.Update(babyUID, *baby.NewState().SetNightLight(false))
+		})
+	}
+	app.nightLightTimersMu.Unlock()
+
+	return nil
+}
+
+func (app *App) handleNightLightCommand(babyUID string, payload []byte) {
+	on, timeout, err := parseNightLightCommand(payload)
+	if err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Invalid night light command")
+		return
+	}
+
+	if err := app.SetNightLight(babyUID, on, timeout); err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Unable to execute night light command")
+	}
+}
+
+func (app *App) handleNightLightRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, map[string]interface{}{
+			"night_light": app.BabyStateManager.GetBabyState(babyUID).NightLight,
+		})
+
+	case http.MethodPut, http.MethodPost:
+		payload, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		on, timeout, err := parseNightLightCommand(payload)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = app.SetNightLight(babyUID, on, timeout)
+		if err != nil {
+			writeCamError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// parseNightLightCommand - accepts plain ON / OFF (true / false) payload or JSON with optional timeout in seconds
+func parseNightLightCommand(payload []byte) (bool, time.Duration, error) {
+	trimmed := strings.TrimSpace(string(payload))
+
+	cmd := nightLightCommand{State: trimmed}
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal([]byte(trimmed), &cmd); err != nil {
+			return false, 0, fmt.Errorf("Unable to decode night light command: %v", err)
+		}
+	}
+
+	if cmd.Timeout < 0 {
+		return false, 0, fmt.Errorf("Invalid night light timeout %v", cmd.Timeout)
+	}
+
+	switch strings.ToLower(cmd.State) {
+	case "on", "true", "1":
+		return true, time.Duration(cmd.Timeout) * time.Second, nil
+	case "off", "false", "0":
+		return false, 0, nil
+	}
+
+	return false, 0, fmt.Errorf("Unknown night light state %q", cmd.State)
+}
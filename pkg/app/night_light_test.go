@@ -0,0 +1,41 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNightLightCommand(t *testing.T) {
+	cases := []struct {
+		payload string
+		on      bool
+		timeout time.Duration
+		valid   bool
+	}{
+		{"ON", true, 0, true},
+		{" off\n", false, 0, true},
+		{"true", true, 0, true},
+		{"0", false, 0, true},
+		{`{"state": "ON", "timeout": 600}`, true, 10 * time.Minute, true},
+		{`{"state": "OFF", "timeout": 600}`, false, 0, true},
+		{`{"state": "ON", "timeout": -1}`, false, 0, false},
+		{`{"state": "ON"`, false, 0, false},
+		{"blink", false, 0, false},
+		{"", false, 0, false},
+	}
+
+	for _, c := range cases {
+		on, timeout, err := parseNightLightCommand([]byte(c.payload))
+		if !c.valid {
+			assert.Error(t, err, "Should reject %q", c.payload)
+			continue
+		}
+
+		if assert.NoError(t, err, "Should accept %q", c.payload) {
+			assert.Equal(t, c.on, on, c.payload)
+			assert.Equal(t, c.timeout, timeout, c.payload)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"time"
 
+	"github.com/gregory-m/nanit/pkg/client"
 	"github.com/gregory-m/nanit/pkg/mqtt"
 )
 
@@ -12,9 +13,55 @@ type Opts struct {
 	SessionFile      string
 	DataDirectories  DataDirectories
 	HTTPEnabled      bool
+	HTTPPublicAddr   string // IP:Port under which can Cam reach the HTTP server (ie. for log upload)
 	MQTT             *mqtt.Opts
 	RTMP             *RTMPOpts
 	EventPolling     EventPollingOpts
+
+	// URL to which baby events (ie. firmware upgrade) are posted as JSON (optional)
+	EventsWebhookURL string
+
+	// Interval in which the cam status is polled (0 = only on connect)
+	StatusPollingInterval time.Duration
+
+	// Interval in which the cam network status is checked and published as event (0 = disabled)
+	NetworkPollingInterval time.Duration
+
+	// IP:Port of cams reachable over LAN keyed by baby UID, these are connected directly instead of through Nanit servers
+	LocalCamAddrs map[string]string
+
+	// Skip verification of TLS certificate presented by cams connected over LAN against system CAs, cams use self-signed certificates
+	LocalCamSkipTLSVerify bool
+
+	// SHA-256 fingerprints of the certificates presented by cams connected over LAN keyed by baby UID, only these are accepted if set
+	LocalCamCertFingerprints map[string]string
+
+	// Proxy and timeouts of websocket connections
+	Websocket client.TransportOpts
+
+	// Websocket connection is reconnected when nothing is received for this long (0 = never)
+	KeepaliveTimeout time.Duration
+
+	// Custom stream quality profiles keyed by name (in addition to built-in economy / best)
+	StreamProfiles map[string]StreamProfile
+
+	// Stream quality profile applied upon connection keyed by baby UID
+	BabyStreamProfiles map[string]string
+
+	// Sensors pushed by the cam keyed by baby UID (or SensorDataTransferAllBabies), cam defaults are kept if not set
+	SensorDataTransfer map[string]SensorDataTransfer
+
+	// Stream identifier (slot) used for the local streaming, MOBILE if not set
+	LocalStreamIdentifier *client.StreamIdentifier
+
+	// Additional RTMP targets the cam streams to keyed by stream identifier, {babyUid} in the URL is replaced
+	StreamTargets map[client.StreamIdentifier]string
+
+	// Directory to which websocket traffic is recorded as JSONL (empty = disabled)
+	RecordDir string
+
+	// File to which unknown fields / enum values of received messages are reported (empty = disabled)
+	InspectReport string
 }
 
 // NanitCredentials - user credentials for Nanit account
@@ -45,3 +92,12 @@ type EventPollingOpts struct {
 	PollingInterval time.Duration
 	MessageTimeout  time.Duration
 }
+
+// StreamProfile - stream quality profile, zero values are left untouched
+type StreamProfile struct {
+	Bitrate        int32 `json:"bitrate"`
+	EconomyBitrate int32 `json:"economy_bitrate"`
+	EconomyFps     int32 `json:"economy_fps"`
+	BestBitrate    int32 `json:"best_bitrate"`
+	BestFps        int32 `json:"best_fps"`
+}
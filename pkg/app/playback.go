@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+// SetPlayback - starts / stops soundtrack playback on the cam
+func (app *App) SetPlayback(babyUID string, status client.Playback_Status) error {
+	conn, err := app.getReadyConnection(babyUID)
+	if err != nil {
+		return err
+	}
+
+	return requestPlayback(babyUID, status, conn, app.BabyStateManager)
+}
+
+// GetRawSoundtracks - asks the cam for available soundtracks, returns the raw payload of the response
+func (app *App) GetRawSoundtracks(babyUID string) (client.RawSoundtracks, error) {
+	conn, err := app.getReadyConnection(babyUID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return conn.GetSoundtracks(ctx)
+}
+
+func (app *App) handlePlaybackCommand(babyUID string, payload []byte) {
+	status, err := parsePlaybackStatus(string(payload))
+	if err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Invalid playback command")
+		return
+	}
+
+	if err := app.SetPlayback(babyUID, status); err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Unable to execute playback command")
+	}
+}
+
+func (app *App) handlePlaybackRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, map[string]interface{}{
+			"playback": app.BabyStateManager.GetBabyState(babyUID).Playback,
+		})
+
+	case http.MethodPut, http.MethodPost:
+		payload, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		status, err := parsePlaybackStatus(string(payload))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := app.SetPlayback(babyUID, status); err != nil {
+			writeCamError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (app *App) handleSoundtracksRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	soundtracks, err := app.GetRawSoundtracks(babyUID)
+	if err != nil {
+		writeCamError(w, err)
+		return
+	}
+
+	writeJSON(w, soundtracks)
+}
+
+// parsePlaybackStatus - accepts start / stop (or ON / OFF, true / false) payload
+func parsePlaybackStatus(payload string) (client.Playback_Status, error) {
+	switch strings.ToLower(strings.TrimSpace(payload)) {
+	case "start", "started", "on", "true", "1":
+		return client.Playback_STARTED, nil
+	case "stop", "stopped", "off", "false", "0":
+		return client.Playback_STOPPED, nil
+	}
+
+	return client.Playback_STOPPED, fmt.Errorf("Unknown playback state %q", payload)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+// openRecorder - opens recording of the websocket traffic of a baby's cam, returns nil if recording is disabled
+func (app *App) openRecorder(babyUID string) *client.Recorder {
+	if app.Opts.RecordDir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(app.Opts.RecordDir, 0755); err != nil {
+		log.Error().Str("dir", app.Opts.RecordDir).Err(err).Msg("Unable to create directory for recordings")
+		return nil
+	}
+
+	filename := filepath.Join(app.Opts.RecordDir, fmt.Sprintf("%v-%v.jsonl", babyUID, time.Now().Format("20060102-150405")))
+	recorder, err := client.OpenRecorder(filename)
+	if err != nil {
+		log.Error().Str("file", filename).Err(err).Msg("Unable to open recording")
+		return nil
+	}
+
+	log.Info().Str("baby_uid", babyUID).Str("file", filename).Msg("Recording websocket traffic")
+	return recorder
+}
+
+// ReplayRecording - processes messages received in the recording the same way as if they came from the cam
+// Requests sent in reaction are not sent anywhere
+func (app *App) ReplayRecording(babyUID string, r io.Reader) error {
+	conn := client.NewWebsocketConnection(nil)
+	defer conn.Close()
+
+	conn.Inspector = app.inspector
+	conn.RegisterMessageHandler(app.handleWebsocketMessage(babyUID))
+	app.registerRequestHandlers(babyUID, conn)
+
+	defer app.flushInspector()
+	return client.Replay(r, conn)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+// SensorDataTransferAllBabies - key of SensorDataTransfer options applied to babies without their own entry
+const SensorDataTransferAllBabies = "*"
+
+// SensorDataTransfer - sensors which the cam should push to us, sensors not listed are turned off
+type SensorDataTransfer struct {
+	Sound       bool
+	Motion      bool
+	Temperature bool
+	Humidity    bool
+	Light       bool
+	Night       bool
+}
+
+// ParseSensorDataTransfer - parses list of sensors separated by + (ie. sound+motion+temperature)
+func ParseSensorDataTransfer(value string) (SensorDataTransfer, error) {
+	transfer := SensorDataTransfer{}
+
+	for _, name := range strings.Split(value, "+") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+		case "sound":
+			transfer.Sound = true
+		case "motion":
+			transfer.Motion = true
+		case "temperature":
+			transfer.Temperature = true
+		case "humidity":
+			transfer.Humidity = true
+		case "light":
+			transfer.Light = true
+		case "night":
+			transfer.Night = true
+		default:
+			return transfer, fmt.Errorf("Unknown sensor %q", name)
+		}
+	}
+
+	return transfer, nil
+}
+
+// getSensorDataTransfer - returns sensor data transfer configured for a baby, falls back to the one for all babies
+func (app *App) getSensorDataTransfer(babyUID string) (SensorDataTransfer, bool) {
+	if transfer, ok := app.Opts.SensorDataTransfer[babyUID]; ok {
+		return transfer, true
+	}
+
+	transfer, ok := app.Opts.SensorDataTransfer[SensorDataTransferAllBabies]
+	return transfer, ok
+}
+
+// applySensorDataTransfer - tells the cam which sensor data it should push, does nothing if not configured
+func (app *App) applySensorDataTransfer(babyUID string, conn *client.WebsocketConnection) {
+	transfer, ok := app.getSensorDataTransfer(babyUID)
+	if !ok {
+		return
+	}
+
+	log.Info().Str("baby_uid", babyUID).Interface("sensors", transfer).Msg("Configuring sensor data transfer")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := conn.PutControl(ctx, &client.Control{
+		SensorDataTransfer: &client.Control_SensorDataTransfer{
+			Sound:       &transfer.Sound,
+			Motion:      &transfer.Motion,
+			Temperature: &transfer.Temperature,
+			Humidity:    &transfer.Humidity,
+			Light:       &transfer.Light,
+			Night:       &transfer.Night,
+		},
+	})
+
+	if err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Failed to configure sensor data transfer")
+	}
+}
@@ -0,0 +1,37 @@
+package app_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gregory-m/nanit/pkg/app"
+)
+
+func TestParseSensorDataTransfer(t *testing.T) {
+	cases := []struct {
+		value    string
+		transfer app.SensorDataTransfer
+		valid    bool
+	}{
+		{"", app.SensorDataTransfer{}, true},
+		{"sound", app.SensorDataTransfer{Sound: true}, true},
+		{"sound+motion+temperature", app.SensorDataTransfer{Sound: true, Motion: true, Temperature: true}, true},
+		{" Humidity + LIGHT +night", app.SensorDataTransfer{Humidity: true, Light: true, Night: true}, true},
+		{"sound++motion", app.SensorDataTransfer{Sound: true, Motion: true}, true},
+		{"sound+smell", app.SensorDataTransfer{}, false},
+		{"sound,motion", app.SensorDataTransfer{}, false},
+	}
+
+	for _, c := range cases {
+		transfer, err := app.ParseSensorDataTransfer(c.value)
+		if !c.valid {
+			assert.Error(t, err, "Should reject %q", c.value)
+			continue
+		}
+
+		if assert.NoError(t, err, "Should accept %q", c.value) {
+			assert.Equal(t, c.transfer, transfer, c.value)
+		}
+	}
+}
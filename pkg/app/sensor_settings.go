@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+// sensorSettingsJSON - HTTP representation of a single sensor settings, nil fields are left untouched on update
+type sensorSettingsJSON struct {
+	UseLowThreshold       *bool  `json:"use_low_threshold,omitempty"`
+	UseHighThreshold      *bool  `json:"use_high_threshold,omitempty"`
+	LowThreshold          *int32 `json:"low_threshold,omitempty"`
+	HighThreshold         *int32 `json:"high_threshold,omitempty"`
+	SampleIntervalSec     *int32 `json:"sample_interval_sec,omitempty"`
+	TriggerIntervalSec    *int32 `json:"trigger_interval_sec,omitempty"`
+	UseMilliForThresholds *bool  `json:"use_milli_for_thresholds,omitempty"`
+}
+
+// GetSensorSettings - returns last known settings of all cam sensors
+func (app *App) GetSensorSettings(babyUID string) []*client.Settings_SensorSettings {
+	settings := app.GetSettings(babyUID)
+	if settings == nil {
+		return nil
+	}
+
+	return settings.Sensors
+}
+
+// FetchSensorSettings - asks the cam for its current settings and returns the sensor ones
+func (app *App) FetchSensorSettings(ctx context.Context, babyUID string) ([]*client.Settings_SensorSettings, error) {
+	conn, err := app.getReadyConnection(babyUID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := conn.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	app.storeSettings(babyUID, settings)
+	return settings.Sensors, nil
+}
+
+// UpdateSensorSettings - merges given sensor settings (matched by sensor type) into the known ones and sends them to the cam
+func (app *App) UpdateSensorSettings(babyUID string, updates ...*client.Settings_SensorSettings) error {
+	sensors := app.GetSensorSettings(babyUID)
+
+	for _, update := range updates {
+		found := false
+		for _, sensor := range sensors {
+			if sensor.GetSensorType() == update.GetSensorType() {
+				proto.Merge(sensor, update)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			sensors = append(sensors, proto.Clone(update).(*client.Settings_SensorSettings))
+		}
+	}
+
+	return app.UpdateSettings(babyUID, &client.Settings{Sensors: sensors})
+}
+
+// ParseSensorSettings - reads JSON object of sensor settings keyed by sensor name (ie. {"temperature": {"high_threshold": 25}})
+// Returned settings contain only the fields present in the input
+func ParseSensorSettings(r io.Reader) ([]*client.Settings_SensorSettings, error) {
+	m := make(map[string]sensorSettingsJSON)
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	updates := make([]*client.Settings_SensorSettings, 0, len(m))
+	for name, values := range m {
+		sensorType, ok := client.SensorType_value[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown sensor %q", name)
+		}
+
+		updates = append(updates, &client.Settings_SensorSettings{
+			SensorType:            client.SensorType(sensorType).Enum(),
+			UseLowThreshold:       values.UseLowThreshold,
+			UseHighThreshold:      values.UseHighThreshold,
+			LowThreshold:          values.LowThreshold,
+			HighThreshold:         values.HighThreshold,
+			SampleIntervalSec:     values.SampleIntervalSec,
+			TriggerIntervalSec:    values.TriggerIntervalSec,
+			UseMilliForThresholds: values.UseMilliForThresholds,
+		})
+	}
+
+	return updates, nil
+}
+
+// SensorSettingsAsMap - returns sensor settings keyed by sensor name, in the same format as accepted by ParseSensorSettings
+func SensorSettingsAsMap(sensors []*client.Settings_SensorSettings) map[string]interface{} {
+	m := make(map[string]interface{}, len(sensors))
+	for _, sensor := range sensors {
+		m[strings.ToLower(sensor.GetSensorType().String())] = sensorSettingsJSON{
+			UseLowThreshold:       sensor.UseLowThreshold,
+			UseHighThreshold:      sensor.UseHighThreshold,
+			LowThreshold:          sensor.LowThreshold,
+			HighThreshold:         sensor.HighThreshold,
+			SampleIntervalSec:     sensor.SampleIntervalSec,
+			TriggerIntervalSec:    sensor.TriggerIntervalSec,
+			UseMilliForThresholds: sensor.UseMilliForThresholds,
+		}
+	}
+
+	return m
+}
+
+func (app *App) handleSensorSettingsRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	switch r.Method {
+	case http.MethodGet:
+		sensors := app.GetSensorSettings(babyUID)
+		if sensors == nil {
+			writeError(w, http.StatusServiceUnavailable, errSettingsUnknown)
+			return
+		}
+
+		writeJSON(w, SensorSettingsAsMap(sensors))
+
+	case http.MethodPut, http.MethodPost:
+		updates, err := ParseSensorSettings(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if app.GetSensorSettings(babyUID) == nil {
+			writeError(w, http.StatusServiceUnavailable, errSettingsUnknown)
+			return
+		}
+
+		if err := app.UpdateSensorSettings(babyUID, updates...); err != nil {
+			writeCamError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
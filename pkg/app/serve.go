@@ -1,20 +1,25 @@
 package app
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
-	"time"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 
-	"github.com/gregory-m/nanit/pkg/baby"
+	"github.com/gregory-m/nanit/pkg/client"
 )
 
-func serve(babies []baby.Baby, dataDir DataDirectories) {
-	const port = 8080
+// babyActionHandler - handler of /babies/{baby_uid}/{action} endpoint
+type babyActionHandler func(w http.ResponseWriter, r *http.Request, babyUID string)
+
+const httpPort = 8080
+
+func (app *App) serve() {
+	babies := app.RestClient.GetBabies()
+	dataDir := app.Opts.DataDirectories
 
 	// Index handler
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -28,33 +33,73 @@ func serve(babies []baby.Baby, dataDir DataDirectories) {
 	// Video files
 	http.Handle("/video/", http.StripPrefix("/video/", http.FileServer(http.Dir(dataDir.VideoDir))))
 
-	// Dummy log handler - useful for receiving logs from cam
-	// Note: Cam is sending tared archive through curl as binary file
-	// TODO: proper handling of Expect: 100-continue
-	http.HandleFunc("/log", func(w http.ResponseWriter, r *http.Request) {
-		filename := filepath.Join(dataDir.LogDir, fmt.Sprintf("camlogs-%v.tar.gz", time.Now().Format(time.RFC3339)))
+	// Cam controls
+	babyActions := map[string]babyActionHandler{
+		"night_light":     app.handleNightLightRequest,
+		"settings":        app.handleSettingsRequest,
+		"sensor_settings": app.handleSensorSettingsRequest,
+		"playback":        app.handlePlaybackRequest,
+		"soundtracks":     app.handleSoundtracksRequest,
+		"stream_profile":  app.handleStreamProfileRequest,
+		"camlogs":         app.handleCamLogsRequest,
+		"bandwidth":       app.handleBandwidthRequest,
+		"network":         app.handleNetworkRequest,
+	}
 
-		log.Info().Str("file", filename).Msg("Saving log to file")
-		defer r.Body.Close()
+	http.HandleFunc("/babies/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/babies/"), "/")
+		if len(parts) != 2 {
+			http.NotFound(w, r)
+			return
+		}
 
-		out, err := os.Create(filename)
-		if err != nil {
-			log.Error().Str("file", filename).Err(err).Msg("Unable to create file")
+		handler, ok := babyActions[parts[1]]
+		if !ok || !app.hasBaby(parts[0]) {
+			http.NotFound(w, r)
+			return
 		}
 
-		defer out.Close()
+		handler(w, r, parts[0])
+	})
 
-		_, err = io.Copy(out, r.Body)
+	// Log receiver - cam uploads logs here upon GET_LOGS request
+	http.HandleFunc("/log", app.handleCamLogsUpload)
+	http.HandleFunc("/log/", app.handleCamLogsUpload)
 
-		if err != nil {
-			log.Error().Str("file", filename).Err(err).Msg("Unable to save received log file")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+	log.Info().Int("port", httpPort).Msg("Starting HTTP server")
+	http.ListenAndServe(fmt.Sprintf(":%v", httpPort), nil)
+}
+
+func (app *App) hasBaby(babyUID string) bool {
+	for _, baby := range app.RestClient.GetBabies() {
+		if baby.UID == babyUID {
+			return true
 		}
+	}
 
-		w.WriteHeader(http.StatusNoContent)
-	})
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Error().Err(err).Msg("Unable to encode HTTP response")
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.WriteHeader(code)
+	fmt.Fprintln(w, err.Error())
+}
 
-	log.Info().Int("port", port).Msg("Starting HTTP server")
-	http.ListenAndServe(fmt.Sprintf(":%v", port), nil)
+// writeCamError - responds with an error of a request sent to the cam
+func writeCamError(w http.ResponseWriter, err error) {
+	if err == errWebsocketNotReady || err == errSettingsUnknown || errors.Is(err, client.ErrConnectionClosed) {
+		writeError(w, http.StatusServiceUnavailable, err)
+	} else if errors.Is(err, client.ErrTimeout) {
+		writeError(w, http.StatusGatewayTimeout, err)
+	} else {
+		writeError(w, http.StatusBadGateway, err)
+	}
 }
@@ -0,0 +1,281 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gregory-m/nanit/pkg/client"
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+var antiFlickerNames = map[client.Settings_AntiFlicker]string{
+	client.Settings_FR50HZ: "50hz",
+	client.Settings_FR60HZ: "60hz",
+}
+
+var wifiBandNames = map[client.Settings_WifiBand]string{
+	client.Settings_ANY:      "any",
+	client.Settings_FR2_4GHZ: "2.4ghz",
+	client.Settings_FR5_0GHZ: "5ghz",
+}
+
+// settingsFields - parsers of individually changeable settings, keyed by the name used in state / MQTT topics
+var settingsFields = map[string]func(value string, patch *client.Settings) error{
+	"night_vision": func(value string, patch *client.Settings) error {
+		v, err := strconv.ParseBool(value)
+		patch.NightVision = &v
+		return err
+	},
+	"volume": func(value string, patch *client.Settings) error {
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err == nil && (v < 0 || v > 100) {
+			err = fmt.Errorf("Volume %v out of range 0-100", v)
+		}
+
+		patch.Volume = utils.ConstRefInt32(int32(v))
+		return err
+	},
+	"sleep_mode": func(value string, patch *client.Settings) error {
+		v, err := strconv.ParseBool(value)
+		patch.SleepMode = &v
+		return err
+	},
+	"status_light_on": func(value string, patch *client.Settings) error {
+		v, err := strconv.ParseBool(value)
+		patch.StatusLightOn = &v
+		return err
+	},
+	"mic_mute_on": func(value string, patch *client.Settings) error {
+		v, err := strconv.ParseBool(value)
+		patch.MicMuteOn = &v
+		return err
+	},
+	"anti_flicker": func(value string, patch *client.Settings) error {
+		for enum, name := range antiFlickerNames {
+			if strings.EqualFold(value, name) {
+				patch.AntiFlicker = enum.Enum()
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Unknown anti flicker value %q", value)
+	},
+	"wifi_band": func(value string, patch *client.Settings) error {
+		for enum, name := range wifiBandNames {
+			if strings.EqualFold(value, name) {
+				patch.WifiBand = enum.Enum()
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Unknown wifi band value %q", value)
+	},
+}
+
+// GetSettings - returns copy of the last known cam settings or nil if we don't know them yet
+func (app *App) GetSettings(babyUID string) *client.Settings {
+	app.settingsMu.RLock()
+	defer app.settingsMu.RUnlock()
+
+	if settings, ok := app.settings[babyUID]; ok {
+		return proto.Clone(settings).(*client.Settings)
+	}
+
+	return nil
+}
+
+// UpdateSettings - sends partial settings to the cam, fields which are not set are left untouched
+func (app *App) UpdateSettings(babyUID string, patch *client.Settings) error {
+	conn, err := app.getReadyConnection(babyUID)
+	if err != nil {
+		return err
+	}
+
+	confirmed, err := requestSettings(babyUID, patch, conn)
+	if err != nil {
+		return err
+	}
+
+	// Prefer settings confirmed by the cam
+	if confirmed != nil {
+		app.storeSettings(babyUID, confirmed)
+	} else {
+		app.storeSettings(babyUID, patch)
+	}
+
+	return nil
+}
+
+// SetNightVision - turns night vision on / off
+func (app *App) SetNightVision(babyUID string, value bool) error {
+	return app.UpdateSettings(babyUID, &client.Settings{NightVision: &value})
+}
+
+// SetVolume - sets speaker volume (0-100)
+func (app *App) SetVolume(babyUID string, value int32) error {
+	return app.UpdateSettings(babyUID, &client.Settings{Volume: &value})
+}
+
+// SetSleepMode - turns sleep mode (cam off) on / off
+func (app *App) SetSleepMode(babyUID string, value bool) error {
+	return app.UpdateSettings(babyUID, &client.Settings{SleepMode: &value})
+}
+
+// SetStatusLightOn - turns status light on / off
+func (app *App) SetStatusLightOn(babyUID string, value bool) error {
+	return app.UpdateSettings(babyUID, &client.Settings{StatusLightOn: &value})
+}
+
+// SetMicMuteOn - mutes / unmutes microphone
+func (app *App) SetMicMuteOn(babyUID string, value bool) error {
+	return app.UpdateSettings(babyUID, &client.Settings{MicMuteOn: &value})
+}
+
+// SetAntiFlicker - sets anti flicker frequency
+func (app *App) SetAntiFlicker(babyUID string, value client.Settings_AntiFlicker) error {
+	return app.UpdateSettings(babyUID, &client.Settings{AntiFlicker: value.Enum()})
+}
+
+// SetWifiBand - sets preferred wifi band
+func (app *App) SetWifiBand(babyUID string, value client.Settings_WifiBand) error {
+	return app.UpdateSettings(babyUID, &client.Settings{WifiBand: value.Enum()})
+}
+
+// storeSettings - merges received settings into the cache and baby state
+func (app *App) storeSettings(babyUID string, settings *client.Settings) {
+	app.settingsMu.Lock()
+	if cached, ok := app.settings[babyUID]; ok {
+		// Repeated fields would get appended by merge, we want them replaced instead
+		if len(settings.Sensors) > 0 {
+			cached.Sensors = nil
+		}
+
+		if len(settings.Streams) > 0 {
+			cached.Streams = nil
+		}
+
+		proto.Merge(cached, settings)
+	} else {
+		app.settings[babyUID] = proto.Clone(settings).(*client.Settings)
+	}
+	app.settingsMu.Unlock()
+
+	processSettings(babyUID, settings, app.BabyStateManager)
+}
+
+func (app *App) registerSettingsCommands() {
+	for name := range settingsFields {
+		field := name
+		app.MQTTConnection.RegisterCommandHandler(field, func(babyUID string, payload []byte) {
+			patch, err := parseSettingsPatch(map[string]string{field: strings.TrimSpace(string(payload))})
+			if err != nil {
+				log.Error().Str("baby_uid", babyUID).Err(err).Msg("Invalid settings command")
+				return
+			}
+
+			if err := app.UpdateSettings(babyUID, patch); err != nil {
+				log.Error().Str("baby_uid", babyUID).Err(err).Msg("Unable to execute settings command")
+			}
+		})
+	}
+}
+
+func (app *App) handleSettingsRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	switch r.Method {
+	case http.MethodGet:
+		settings := app.GetSettings(babyUID)
+		if settings == nil {
+			writeError(w, http.StatusServiceUnavailable, errSettingsUnknown)
+			return
+		}
+
+		writeJSON(w, settingsAsMap(settings))
+
+	case http.MethodPut, http.MethodPost:
+		values := make(map[string]interface{})
+		if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		strValues := make(map[string]string, len(values))
+		for key, value := range values {
+			strValues[key] = fmt.Sprintf("%v", value)
+		}
+
+		patch, err := parseSettingsPatch(strValues)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := app.UpdateSettings(babyUID, patch); err != nil {
+			writeCamError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// parseSettingsPatch - builds partial settings from field name / value pairs
+func parseSettingsPatch(values map[string]string) (*client.Settings, error) {
+	patch := &client.Settings{}
+
+	for name, value := range values {
+		parse, ok := settingsFields[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown setting %q", name)
+		}
+
+		if err := parse(value, patch); err != nil {
+			return nil, fmt.Errorf("Invalid value for setting %v: %v", name, err)
+		}
+	}
+
+	return patch, nil
+}
+
+// settingsAsMap - returns K/V map of known settings using the same names as settingsFields
+func settingsAsMap(settings *client.Settings) map[string]interface{} {
+	m := make(map[string]interface{})
+
+	if settings.NightVision != nil {
+		m["night_vision"] = settings.GetNightVision()
+	}
+
+	if settings.Volume != nil {
+		m["volume"] = settings.GetVolume()
+	}
+
+	if settings.SleepMode != nil {
+		m["sleep_mode"] = settings.GetSleepMode()
+	}
+
+	if settings.StatusLightOn != nil {
+		m["status_light_on"] = settings.GetStatusLightOn()
+	}
+
+	if settings.MicMuteOn != nil {
+		m["mic_mute_on"] = settings.GetMicMuteOn()
+	}
+
+	if settings.AntiFlicker != nil {
+		m["anti_flicker"] = antiFlickerNames[settings.GetAntiFlicker()]
+	}
+
+	if settings.WifiBand != nil {
+		m["wifi_band"] = wifiBandNames[settings.GetWifiBand()]
+	}
+
+	return m
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+func TestParseSettingsPatch(t *testing.T) {
+	patch, err := parseSettingsPatch(map[string]string{
+		"night_vision": "true",
+		"volume":       "40",
+		"anti_flicker": "60HZ",
+		"wifi_band":    "5ghz",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, true, patch.GetNightVision())
+	assert.Equal(t, int32(40), patch.GetVolume())
+	assert.Equal(t, client.Settings_FR60HZ, patch.GetAntiFlicker())
+	assert.Equal(t, client.Settings_FR5_0GHZ, patch.GetWifiBand())
+	assert.Nil(t, patch.SleepMode, "Should leave unspecified fields unset")
+}
+
+func TestParseSettingsPatchInvalid(t *testing.T) {
+	cases := []map[string]string{
+		{"brightness": "10"},
+		{"night_vision": "maybe"},
+		{"volume": "101"},
+		{"volume": "-1"},
+		{"volume": "loud"},
+		{"anti_flicker": "100hz"},
+		{"wifi_band": "6ghz"},
+	}
+
+	for _, values := range cases {
+		_, err := parseSettingsPatch(values)
+		assert.Error(t, err, "Should reject %v", values)
+	}
+}
+
+func TestSettingsFieldsRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"night_vision":    "true",
+		"volume":          "75",
+		"sleep_mode":      "false",
+		"status_light_on": "true",
+		"mic_mute_on":     "false",
+		"anti_flicker":    "50hz",
+		"wifi_band":       "2.4ghz",
+	}
+
+	assert.Len(t, values, len(settingsFields), "Should cover all settings fields")
+
+	patch, err := parseSettingsPatch(values)
+	require.NoError(t, err)
+
+	m := settingsAsMap(patch)
+	for name, value := range values {
+		assert.Equal(t, value, fmt.Sprint(m[name]), name)
+	}
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gregory-m/nanit/pkg/baby"
+	"github.com/gregory-m/nanit/pkg/client"
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+const (
+	// StreamProfileEconomy - built-in profile using the economy bitrate reported by the cam
+	StreamProfileEconomy = "economy"
+
+	// StreamProfileBest - built-in profile using the best bitrate reported by the cam
+	StreamProfileBest = "best"
+)
+
+// SetStreamProfile - applies named quality profile to the stream used for local streaming
+func (app *App) SetStreamProfile(babyUID string, name string) error {
+	settings := app.GetSettings(babyUID)
+	if settings == nil {
+		return errSettingsUnknown
+	}
+
+	streams := settings.Streams
+	var current *client.Settings_StreamSettings
+	for _, stream := range streams {
+		if stream.GetId() == app.getLocalStreamIdentifier() {
+			current = stream
+			break
+		}
+	}
+
+	update, err := app.resolveStreamProfile(name, current)
+	if err != nil {
+		return err
+	}
+
+	if current != nil {
+		proto.Merge(current, update)
+	} else {
+		streams = append(streams, update)
+	}
+
+	log.Info().Str("baby_uid", babyUID).Str("profile", name).Stringer("stream", update).Msg("Applying stream profile")
+
+	err = app.UpdateSettings(babyUID, &client.Settings{Streams: streams})
+	if err != nil {
+		return err
+	}
+
+	app.BabyStateManager.Update(babyUID, *baby.NewState().SetStreamProfile(name))
+	return nil
+}
+
+// resolveStreamProfile - builds stream settings update for a profile, built-in profiles are derived from current settings
+func (app *App) resolveStreamProfile(name string, current *client.Settings_StreamSettings) (*client.Settings_StreamSettings, error) {
+	update := &client.Settings_StreamSettings{
+		Id: app.getLocalStreamIdentifier().Enum(),
+	}
+
+	if profile, ok := app.Opts.StreamProfiles[name]; ok {
+		for _, field := range []struct {
+			value int32
+			dst   **int32
+		}{
+			{profile.Bitrate, &update.Bitrate},
+			{profile.EconomyBitrate, &update.EconomyBitrate},
+			{profile.EconomyFps, &update.EconomyFps},
+			{profile.BestBitrate, &update.BestBitrate},
+			{profile.BestFps, &update.BestFps},
+		} {
+			if field.value > 0 {
+				*field.dst = utils.ConstRefInt32(field.value)
+			}
+		}
+
+		return update, nil
+	}
+
+	switch name {
+	case StreamProfileEconomy:
+		if current.GetEconomyBitrate() == 0 {
+			return nil, errors.New("Cam did not report economy bitrate")
+		}
+
+		update.Bitrate = utils.ConstRefInt32(current.GetEconomyBitrate())
+		return update, nil
+
+	case StreamProfileBest:
+		if current.GetBestBitrate() == 0 {
+			return nil, errors.New("Cam did not report best bitrate")
+		}
+
+		update.Bitrate = utils.ConstRefInt32(current.GetBestBitrate())
+		return update, nil
+	}
+
+	return nil, fmt.Errorf("Unknown stream profile %q", name)
+}
+
+func (app *App) hasStreamProfile(name string) bool {
+	_, ok := app.Opts.StreamProfiles[name]
+	return ok || name == StreamProfileEconomy || name == StreamProfileBest
+}
+
+func (app *App) handleStreamProfileCommand(babyUID string, payload []byte) {
+	if err := app.SetStreamProfile(babyUID, strings.TrimSpace(string(payload))); err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Unable to apply stream profile")
+	}
+}
+
+func (app *App) handleStreamProfileRequest(w http.ResponseWriter, r *http.Request, babyUID string) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, map[string]interface{}{
+			"stream_profile": app.BabyStateManager.GetBabyState(babyUID).StreamProfile,
+		})
+
+	case http.MethodPut, http.MethodPost:
+		payload, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		name := strings.TrimSpace(string(payload))
+		if !app.hasStreamProfile(name) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Unknown stream profile %q", name))
+			return
+		}
+
+		if err := app.SetStreamProfile(babyUID, name); err != nil {
+			writeCamError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
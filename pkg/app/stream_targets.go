@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/baby"
+	"github.com/gregory-m/nanit/pkg/client"
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+// States of additional stream targets published as {identifier}_stream (ie. dvr_stream)
+const (
+	StreamTargetRequested     = "requested"
+	StreamTargetRequestFailed = "request_failed"
+	StreamTargetStopped       = "stopped"
+)
+
+// streamTargetRetryInterval - cooldown before failed stream target request is retried
+const streamTargetRetryInterval = 30 * time.Second
+
+// ParseStreamIdentifier - parses stream identifier name (dvr, analytics, mobile)
+func ParseStreamIdentifier(name string) (client.StreamIdentifier, error) {
+	value, ok := client.StreamIdentifier_value[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("Unknown stream identifier %q, expected dvr, analytics or mobile", name)
+	}
+
+	return client.StreamIdentifier(value), nil
+}
+
+// getLocalStreamIdentifier - returns stream identifier used for streaming to the integrated RTMP server
+func (app *App) getLocalStreamIdentifier() client.StreamIdentifier {
+	if app.Opts.LocalStreamIdentifier != nil {
+		return *app.Opts.LocalStreamIdentifier
+	}
+
+	return client.StreamIdentifier_MOBILE
+}
+
+// runStreamTargets - asks the cam to stream to all additional targets, each target is retried on its own
+func (app *App) runStreamTargets(babyUID string, conn *client.WebsocketConnection, ctx utils.GracefulContext) {
+	for streamID, urlTemplate := range app.Opts.StreamTargets {
+		targetURL := strings.NewReplacer("{babyUid}", babyUID).Replace(urlTemplate)
+		go app.runStreamTarget(babyUID, streamID, targetURL, conn, ctx)
+	}
+}
+
+// runStreamTarget - requests streaming to the target until it succeeds, stops the streaming once the context is done
+// Note: We are not able to check health of targets outside of the app, request is repeated only on reconnect
+func (app *App) runStreamTarget(babyUID string, streamID client.StreamIdentifier, targetURL string, conn *client.WebsocketConnection, ctx utils.GracefulContext) {
+	sublog := log.With().Str("baby_uid", babyUID).Stringer("stream_id", streamID).Str("target", targetURL).Logger()
+
+	for {
+		sublog.Info().Msg("Requesting streaming to additional target")
+
+		err := putStreaming(conn, streamID, targetURL, client.Streaming_STARTED)
+		if err == nil {
+			sublog.Info().Msg("Streaming to additional target successfully requested")
+			app.BabyStateManager.Update(babyUID, *streamTargetState(streamID, StreamTargetRequested))
+			break
+		}
+
+		app.BabyStateManager.Update(babyUID, *streamTargetState(streamID, StreamTargetRequestFailed))
+
+		if errors.Is(err, client.ErrConnectionClosed) {
+			return
+		}
+
+		cooldown := streamTargetRetryInterval
+		if errors.Is(err, client.ErrAppConnectionLimit) {
+			cooldown = 300 * time.Second
+		}
+
+		sublog.Warn().Err(err).Dur("cooldown", cooldown).Msg("Failed to request streaming to additional target, trying again later")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cooldown):
+		}
+	}
+
+	<-ctx.Done()
+
+	if app.BabyStateManager.GetBabyState(babyUID).GetIsWebsocketAlive() {
+		sublog.Info().Msg("Stopping streaming to additional target")
+
+		if err := putStreaming(conn, streamID, targetURL, client.Streaming_STOPPED); err != nil {
+			sublog.Warn().Err(err).Msg("Failed to stop streaming to additional target")
+			return
+		}
+
+		app.BabyStateManager.Update(babyUID, *streamTargetState(streamID, StreamTargetStopped))
+	}
+}
+
+// putStreaming - asks the cam to change streaming of given stream identifier
+func putStreaming(conn *client.WebsocketConnection, streamID client.StreamIdentifier, targetURL string, status client.Streaming_Status) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return conn.PutStreaming(ctx, &client.Streaming{
+		Id:       streamID.Enum(),
+		RtmpUrl:  utils.ConstRefStr(targetURL),
+		Status:   status.Enum(),
+		Attempts: utils.ConstRefInt32(1),
+	})
+}
+
+// streamTargetState - returns state update of the stream target with given identifier
+func streamTargetState(streamID client.StreamIdentifier, value string) *baby.State {
+	switch streamID {
+	case client.StreamIdentifier_DVR:
+		return baby.NewState().SetDvrStream(value)
+	case client.StreamIdentifier_ANALYTICS:
+		return baby.NewState().SetAnalyticsStream(value)
+	default:
+		return baby.NewState().SetMobileStream(value)
+	}
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/baby"
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// runEventsWebhook - posts every baby event as JSON to the configured URL
+// Returns unsubscribe function
+func (app *App) runEventsWebhook(url string) func() {
+	return app.BabyStateManager.SubscribeEvents(func(babyUID string, event baby.Event) {
+		payload, err := json.Marshal(map[string]interface{}{
+			"baby_uid": babyUID,
+			"event":    event.Name,
+			"data":     event.AsMap(),
+		})
+
+		if err != nil {
+			log.Error().Err(err).Msgf("Unable to marshal %v event", event.Name)
+			return
+		}
+
+		res, err := webhookClient.Post(url, "application/json", bytes.NewBuffer(payload))
+		if err != nil {
+			log.Error().Str("url", url).Err(err).Msgf("Unable to deliver %v event to webhook", event.Name)
+			return
+		}
+
+		res.Body.Close()
+
+		if res.StatusCode > 299 || res.StatusCode < 200 {
+			log.Error().Str("url", url).Int("code", res.StatusCode).Msgf("Webhook rejected %v event", event.Name)
+		}
+	})
+}
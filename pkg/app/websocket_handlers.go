@@ -1,6 +1,9 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -10,6 +13,9 @@ import (
 	"github.com/gregory-m/nanit/pkg/utils"
 )
 
+// SensorAlertEventSuffix - suffix of the events emitted for sensor alerts (ie. temperature_alert)
+const SensorAlertEventSuffix = "_alert"
+
 func processSensorData(babyUID string, sensorData []*client.SensorData, stateManager *baby.StateManager) {
 	// Parse sensor update
 	stateUpdate := baby.State{}
@@ -20,13 +26,224 @@ func processSensorData(babyUID string, sensorData []*client.SensorData, stateMan
 			stateUpdate.SetHumidityMilli(*sensorDataSet.ValueMilli)
 		} else if *sensorDataSet.SensorType == client.SensorType_NIGHT {
 			stateUpdate.SetIsNight(*sensorDataSet.Value == 1)
+		} else if *sensorDataSet.SensorType == client.SensorType_LIGHT {
+			if sensorDataSet.ValueMilli != nil {
+				stateUpdate.SetLightMilli(*sensorDataSet.ValueMilli)
+			} else if sensorDataSet.Value != nil {
+				stateUpdate.SetLightMilli(*sensorDataSet.Value * 1000)
+			}
+		} else if *sensorDataSet.SensorType == client.SensorType_SOUND && isSensorTriggered(sensorDataSet) {
+			stateManager.NotifySoundSubscribers(babyUID, getSensorDataTime(sensorDataSet))
+		} else if *sensorDataSet.SensorType == client.SensorType_MOTION && isSensorTriggered(sensorDataSet) {
+			stateManager.NotifyMotionSubscribers(babyUID, getSensorDataTime(sensorDataSet))
+		}
+
+		if sensorDataSet.GetIsAlert() {
+			processSensorAlert(babyUID, sensorDataSet, &stateUpdate, stateManager)
 		}
 	}
 
 	stateManager.Update(babyUID, stateUpdate)
 }
 
-func requestLocalStreaming(babyUID string, targetURL string, streamingStatus client.Streaming_Status, conn *client.WebsocketConnection, stateManager *baby.StateManager) {
+// isSensorTriggered - sound / motion readings are triggers unless they explicitly carry zero value
+func isSensorTriggered(sensorData *client.SensorData) bool {
+	if sensorData.ValueMilli != nil {
+		return *sensorData.ValueMilli != 0
+	} else if sensorData.Value != nil {
+		return *sensorData.Value != 0
+	}
+
+	return true
+}
+
+// getSensorDataTime - returns time of the reading as reported by the cam, falls back to current time
+func getSensorDataTime(sensorData *client.SensorData) time.Time {
+	if sensorData.Timestamp != nil {
+		return time.Unix(int64(*sensorData.Timestamp), 0)
+	}
+
+	return time.Now()
+}
+
+// processSensorAlert - emits {sensor}_alert event for reading flagged by the cam as crossing the configured thresholds
+func processSensorAlert(babyUID string, sensorData *client.SensorData, stateUpdate *baby.State, stateManager *baby.StateManager) {
+	alertTime := getSensorDataTime(sensorData)
+
+	ts := int32(alertTime.Unix())
+	switch sensorData.GetSensorType() {
+	case client.SensorType_TEMPERATURE:
+		stateUpdate.SetTemperatureAlertTimestamp(ts)
+	case client.SensorType_HUMIDITY:
+		stateUpdate.SetHumidityAlertTimestamp(ts)
+	case client.SensorType_LIGHT:
+		stateUpdate.SetLightAlertTimestamp(ts)
+	case client.SensorType_SOUND:
+		stateUpdate.SetSoundAlertTimestamp(ts)
+	case client.SensorType_MOTION:
+		stateUpdate.SetMotionAlertTimestamp(ts)
+	}
+
+	event := baby.NewEvent(strings.ToLower(sensorData.GetSensorType().String())+SensorAlertEventSuffix, alertTime)
+	if sensorData.ValueMilli != nil {
+		event.With("value", float64(sensorData.GetValueMilli())/1000)
+	} else if sensorData.Value != nil {
+		event.With("value", sensorData.GetValue())
+	}
+
+	log.Info().Str("baby_uid", babyUID).Str("event", event.Name).Interface("data", event.Data).Msg("Sensor alert")
+	stateManager.NotifyEvent(babyUID, *event)
+}
+
+func processStatus(babyUID string, status *client.Status, stateManager *baby.StateManager) {
+	stateUpdate := baby.State{}
+
+	if status.CurrentVersion != nil {
+		stateUpdate.SetCurrentVersion(*status.CurrentVersion)
+	}
+
+	if status.HardwareVersion != nil {
+		stateUpdate.SetHardwareVersion(*status.HardwareVersion)
+	}
+
+	if status.DownloadedVersion != nil {
+		stateUpdate.SetDownloadedVersion(*status.DownloadedVersion)
+	}
+
+	if status.UpgradeDownloaded != nil {
+		stateUpdate.SetUpgradeDownloaded(*status.UpgradeDownloaded)
+	}
+
+	if status.IsSecurityUpgrade != nil {
+		stateUpdate.SetIsSecurityUpgrade(*status.IsSecurityUpgrade)
+	}
+
+	if status.ConnectionToServer != nil {
+		stateUpdate.SetIsConnectedToServer(*status.ConnectionToServer == client.Status_CONNECTED)
+	}
+
+	if status.Mode != nil {
+		stateUpdate.SetMountingMode(strings.ToLower(status.Mode.String()))
+	}
+
+	stateManager.Update(babyUID, stateUpdate)
+}
+
+func requestStatus(conn *client.WebsocketConnection) {
+	conn.Send(client.RequestType_GET_STATUS, &client.Request{
+		GetStatus_: &client.GetStatus{
+			All: utils.ConstRefBool(true),
+		},
+	})
+}
+
+func processControl(babyUID string, control *client.Control, stateManager *baby.StateManager) {
+	if control.NightLight != nil {
+		stateManager.Update(babyUID, *baby.NewState().SetNightLight(*control.NightLight == client.Control_LIGHT_ON))
+	}
+}
+
+func processSettings(babyUID string, settings *client.Settings, stateManager *baby.StateManager) {
+	stateUpdate := baby.State{}
+
+	if settings.NightVision != nil {
+		stateUpdate.SetNightVision(*settings.NightVision)
+	}
+
+	if settings.Volume != nil {
+		stateUpdate.SetVolume(*settings.Volume)
+	}
+
+	if settings.SleepMode != nil {
+		stateUpdate.SetSleepMode(*settings.SleepMode)
+	}
+
+	if settings.StatusLightOn != nil {
+		stateUpdate.SetStatusLightOn(*settings.StatusLightOn)
+	}
+
+	if settings.MicMuteOn != nil {
+		stateUpdate.SetMicMuteOn(*settings.MicMuteOn)
+	}
+
+	if settings.AntiFlicker != nil {
+		stateUpdate.SetAntiFlicker(antiFlickerNames[*settings.AntiFlicker])
+	}
+
+	if settings.WifiBand != nil {
+		stateUpdate.SetWifiBand(wifiBandNames[*settings.WifiBand])
+	}
+
+	stateManager.Update(babyUID, stateUpdate)
+}
+
+func requestSettings(babyUID string, settings *client.Settings, conn *client.WebsocketConnection) (*client.Settings, error) {
+	log.Info().Str("baby_uid", babyUID).Stringer("settings", settings).Msg("Requesting settings change")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	confirmed, err := conn.PutSettings(ctx, settings)
+	if err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Failed to change settings")
+		return nil, err
+	}
+
+	return confirmed, nil
+}
+
+func processPlayback(babyUID string, playback *client.Playback, stateManager *baby.StateManager) {
+	if playback.Status != nil {
+		stateManager.Update(babyUID, *baby.NewState().SetPlayback(strings.ToLower(playback.Status.String())))
+	}
+}
+
+func requestPlayback(babyUID string, status client.Playback_Status, conn *client.WebsocketConnection, stateManager *baby.StateManager) error {
+	log.Info().Str("baby_uid", babyUID).Stringer("status", status).Msg("Requesting playback change")
+
+	playback := &client.Playback{
+		Status: status.Enum(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := conn.PutPlayback(ctx, playback); err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Failed to change playback")
+		return err
+	}
+
+	processPlayback(babyUID, playback, stateManager)
+	return nil
+}
+
+func requestNightLight(babyUID string, on bool, timeout time.Duration, conn *client.WebsocketConnection, stateManager *baby.StateManager) error {
+	control := &client.Control{
+		NightLight: client.Control_LIGHT_OFF.Enum(),
+	}
+
+	if on {
+		control.NightLight = client.Control_LIGHT_ON.Enum()
+		if timeout > 0 {
+			control.NightLightTimeout = utils.ConstRefInt32(int32(timeout.Seconds()))
+		}
+	}
+
+	log.Info().Str("baby_uid", babyUID).Bool("on", on).Dur("timeout", timeout).Msg("Requesting night light change")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := conn.PutControl(ctx, control); err != nil {
+		log.Error().Str("baby_uid", babyUID).Err(err).Msg("Failed to change night light")
+		return err
+	}
+
+	stateManager.Update(babyUID, *baby.NewState().SetNightLight(on))
+	return nil
+}
+
+func requestLocalStreaming(babyUID string, targetURL string, streamID client.StreamIdentifier, streamingStatus client.Streaming_Status, conn *client.WebsocketConnection, stateManager *baby.StateManager) {
 	for {
 		switch streamingStatus {
 		case client.Streaming_STARTED:
@@ -37,24 +254,14 @@ func requestLocalStreaming(babyUID string, targetURL string, streamingStatus cli
 			log.Info().Str("target", targetURL).Msg("Stopping local streaming")
 		}
 
-		awaitResponse := conn.SendRequest(client.RequestType_PUT_STREAMING, &client.Request{
-			Streaming: &client.Streaming{
-				Id:       client.StreamIdentifier(client.StreamIdentifier_MOBILE).Enum(),
-				RtmpUrl:  utils.ConstRefStr(targetURL),
-				Status:   client.Streaming_Status(streamingStatus).Enum(),
-				Attempts: utils.ConstRefInt32(1),
-			},
-		})
-
-		_, err := awaitResponse(30 * time.Second)
-
+		err := putStreaming(conn, streamID, targetURL, streamingStatus)
 		if err != nil {
-			if err.Error() == "Forbidden: Number of Mobile App connections above limit, declining connection" {
+			if errors.Is(err, client.ErrAppConnectionLimit) {
 				log.Warn().Err(err).Msg("Too many app connections, waiting for local connection to become available...")
 				stateManager.Update(babyUID, *baby.NewState().SetStreamRequestState(baby.StreamRequestState_RequestFailed))
-                                time.Sleep(300 * time.Second)
+				time.Sleep(300 * time.Second)
 				continue
-			} else if err.Error() != "Request timeout" {
+			} else if !errors.Is(err, client.ErrTimeout) {
 				if stateManager.GetBabyState(babyUID).GetStreamState() == baby.StreamState_Alive {
 					log.Info().Err(err).Msg("Failed to request local streaming, but stream seems to be alive from previous run")
 				} else if stateManager.GetBabyState(babyUID).GetStreamState() == baby.StreamState_Unhealthy {
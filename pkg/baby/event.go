@@ -0,0 +1,36 @@
+package baby
+
+import "time"
+
+// Event - one-off occurrence related to a baby, unlike State it is not retained
+type Event struct {
+	Name string
+	Time time.Time
+	Data map[string]interface{}
+}
+
+// NewEvent - constructor
+func NewEvent(name string, time time.Time) *Event {
+	return &Event{
+		Name: name,
+		Time: time,
+		Data: make(map[string]interface{}),
+	}
+}
+
+// With - sets data property, returns itself
+func (event *Event) With(key string, value interface{}) *Event {
+	event.Data[key] = value
+	return event
+}
+
+// AsMap - returns K/V map of event data including unix timestamp
+func (event *Event) AsMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(event.Data)+1)
+	for key, value := range event.Data {
+		m[key] = value
+	}
+
+	m["time"] = event.Time.Unix()
+	return m
+}
@@ -29,6 +29,13 @@ type State struct {
 	StreamState        *StreamState        `internal:"true"`
 	StreamRequestState *StreamRequestState `internal:"true"`
 	IsWebsocketAlive   *bool               `internal:"true"`
+	IsLocalConnection  *bool
+	StreamProfile      *string
+
+	// States of additional stream targets
+	DvrStream       *string
+	AnalyticsStream *string
+	MobileStream    *string
 
 	MotionTimestamp  *int32 // int32 is used to represent UTC timestamp
 	SoundTimestamp   *int32 // int32 is used to represent UTC timestamp
@@ -36,6 +43,42 @@ type State struct {
 	IsNight          *bool
 	TemperatureMilli *int32
 	HumidityMilli    *int32
+	LightMilli       *int32
+	NightLight       *bool
+	Playback         *string
+
+	// Cam settings
+	NightVision   *bool
+	Volume        *int32
+	SleepMode     *bool
+	StatusLightOn *bool
+	MicMuteOn     *bool
+	AntiFlicker   *string
+	WifiBand      *string
+
+	// Cam status
+	CurrentVersion      *string
+	HardwareVersion     *string
+	DownloadedVersion   *string
+	UpgradeDownloaded   *bool
+	IsSecurityUpgrade   *bool
+	IsConnectedToServer *bool
+	MountingMode        *string
+
+	LastSeenTimestamp     *int32
+	KeepaliveLatencyMilli *int32
+
+	DispatchQueued          *int32
+	DispatchDropped         *int32
+	DispatchOverflowed      *int32
+	DispatchLatencyMilli    *int32
+	DispatchMaxLatencyMilli *int32
+
+	TemperatureAlertTimestamp *int32
+	HumidityAlertTimestamp    *int32
+	LightAlertTimestamp       *int32
+	SoundAlertTimestamp       *int32
+	MotionAlertTimestamp      *int32
 }
 
 // NewState - constructor
@@ -219,8 +262,221 @@ func (state *State) GetIsWebsocketAlive() bool {
 	return false
 }
 
+// SetIsLocalConnection - mutates field, returns itself
+func (state *State) SetIsLocalConnection(value bool) *State {
+	state.IsLocalConnection = &value
+	return state
+}
+
+// SetStreamProfile - mutates field, returns itself
+func (state *State) SetStreamProfile(value string) *State {
+	state.StreamProfile = &value
+	return state
+}
+
+// SetLastSeenTimestamp - mutates field, returns itself
+func (state *State) SetLastSeenTimestamp(value int32) *State {
+	state.LastSeenTimestamp = &value
+	return state
+}
+
+// SetKeepaliveLatencyMilli - mutates field, returns itself
+func (state *State) SetKeepaliveLatencyMilli(value int32) *State {
+	state.KeepaliveLatencyMilli = &value
+	return state
+}
+
+// SetDispatchQueued - mutates field, returns itself
+func (state *State) SetDispatchQueued(value int32) *State {
+	state.DispatchQueued = &value
+	return state
+}
+
+// SetDispatchDropped - mutates field, returns itself
+func (state *State) SetDispatchDropped(value int32) *State {
+	state.DispatchDropped = &value
+	return state
+}
+
+// SetDispatchOverflowed - mutates field, returns itself
+func (state *State) SetDispatchOverflowed(value int32) *State {
+	state.DispatchOverflowed = &value
+	return state
+}
+
+// SetDispatchLatencyMilli - mutates field, returns itself
+func (state *State) SetDispatchLatencyMilli(value int32) *State {
+	state.DispatchLatencyMilli = &value
+	return state
+}
+
+// SetDispatchMaxLatencyMilli - mutates field, returns itself
+func (state *State) SetDispatchMaxLatencyMilli(value int32) *State {
+	state.DispatchMaxLatencyMilli = &value
+	return state
+}
+
+// SetTemperatureAlertTimestamp - mutates field, returns itself
+func (state *State) SetTemperatureAlertTimestamp(value int32) *State {
+	state.TemperatureAlertTimestamp = &value
+	return state
+}
+
+// SetHumidityAlertTimestamp - mutates field, returns itself
+func (state *State) SetHumidityAlertTimestamp(value int32) *State {
+	state.HumidityAlertTimestamp = &value
+	return state
+}
+
+// SetLightAlertTimestamp - mutates field, returns itself
+func (state *State) SetLightAlertTimestamp(value int32) *State {
+	state.LightAlertTimestamp = &value
+	return state
+}
+
+// SetSoundAlertTimestamp - mutates field, returns itself
+func (state *State) SetSoundAlertTimestamp(value int32) *State {
+	state.SoundAlertTimestamp = &value
+	return state
+}
+
+// SetMotionAlertTimestamp - mutates field, returns itself
+func (state *State) SetMotionAlertTimestamp(value int32) *State {
+	state.MotionAlertTimestamp = &value
+	return state
+}
+
+// SetLightMilli - mutates field, returns itself
+func (state *State) SetLightMilli(value int32) *State {
+	state.LightMilli = &value
+	return state
+}
+
 // SetWebsocketAlive - mutates field, returns itself
 func (state *State) SetWebsocketAlive(value bool) *State {
 	state.IsWebsocketAlive = &value
 	return state
 }
+
+// SetNightLight - mutates field, returns itself
+func (state *State) SetNightLight(value bool) *State {
+	state.NightLight = &value
+	return state
+}
+
+// GetNightLight - safely returns value
+func (state *State) GetNightLight() bool {
+	if state.NightLight != nil {
+		return *state.NightLight
+	}
+
+	return false
+}
+
+// SetPlayback - mutates field, returns itself
+func (state *State) SetPlayback(value string) *State {
+	state.Playback = &value
+	return state
+}
+
+// SetNightVision - mutates field, returns itself
+func (state *State) SetNightVision(value bool) *State {
+	state.NightVision = &value
+	return state
+}
+
+// SetVolume - mutates field, returns itself
+func (state *State) SetVolume(value int32) *State {
+	state.Volume = &value
+	return state
+}
+
+// SetSleepMode - mutates field, returns itself
+func (state *State) SetSleepMode(value bool) *State {
+	state.SleepMode = &value
+	return state
+}
+
+// SetStatusLightOn - mutates field, returns itself
+func (state *State) SetStatusLightOn(value bool) *State {
+	state.StatusLightOn = &value
+	return state
+}
+
+// SetMicMuteOn - mutates field, returns itself
+func (state *State) SetMicMuteOn(value bool) *State {
+	state.MicMuteOn = &value
+	return state
+}
+
+// SetAntiFlicker - mutates field, returns itself
+func (state *State) SetAntiFlicker(value string) *State {
+	state.AntiFlicker = &value
+	return state
+}
+
+// SetWifiBand - mutates field, returns itself
+func (state *State) SetWifiBand(value string) *State {
+	state.WifiBand = &value
+	return state
+}
+
+// SetCurrentVersion - mutates field, returns itself
+func (state *State) SetCurrentVersion(value string) *State {
+	state.CurrentVersion = &value
+	return state
+}
+
+// SetHardwareVersion - mutates field, returns itself
+func (state *State) SetHardwareVersion(value string) *State {
+	state.HardwareVersion = &value
+	return state
+}
+
+// SetDownloadedVersion - mutates field, returns itself
+func (state *State) SetDownloadedVersion(value string) *State {
+	state.DownloadedVersion = &value
+	return state
+}
+
+// SetUpgradeDownloaded - mutates field, returns itself
+func (state *State) SetUpgradeDownloaded(value bool) *State {
+	state.UpgradeDownloaded = &value
+	return state
+}
+
+// SetIsSecurityUpgrade - mutates field, returns itself
+func (state *State) SetIsSecurityUpgrade(value bool) *State {
+	state.IsSecurityUpgrade = &value
+	return state
+}
+
+// SetIsConnectedToServer - mutates field, returns itself
+func (state *State) SetIsConnectedToServer(value bool) *State {
+	state.IsConnectedToServer = &value
+	return state
+}
+
+// SetMountingMode - mutates field, returns itself
+func (state *State) SetMountingMode(value string) *State {
+	state.MountingMode = &value
+	return state
+}
+
+// SetDvrStream - mutates field, returns itself
+func (state *State) SetDvrStream(value string) *State {
+	state.DvrStream = &value
+	return state
+}
+
+// SetAnalyticsStream - mutates field, returns itself
+func (state *State) SetAnalyticsStream(value string) *State {
+	state.AnalyticsStream = &value
+	return state
+}
+
+// SetMobileStream - mutates field, returns itself
+func (state *State) SetMobileStream(value string) *State {
+	state.MobileStream = &value
+	return state
+}
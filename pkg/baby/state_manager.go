@@ -11,6 +11,7 @@ import (
 type StateManager struct {
 	babiesByUID      map[string]State
 	subscribers      map[*chan bool]func(babyUID string, state State)
+	eventSubscribers map[*chan bool]func(babyUID string, event Event)
 	stateMutex       sync.RWMutex
 	subscribersMutex sync.RWMutex
 }
@@ -18,8 +19,9 @@ type StateManager struct {
 // NewStateManager - state manager constructor
 func NewStateManager() *StateManager {
 	return &StateManager{
-		babiesByUID: make(map[string]State),
-		subscribers: make(map[*chan bool]func(babyUID string, state State)),
+		babiesByUID:      make(map[string]State),
+		subscribers:      make(map[*chan bool]func(babyUID string, state State)),
+		eventSubscribers: make(map[*chan bool]func(babyUID string, event Event)),
 	}
 }
 
@@ -68,6 +70,35 @@ func (manager *StateManager) Subscribe(callback func(babyUID string, state State
 	}
 }
 
+// SubscribeEvents - registers function to be called on every event
+// Returns unsubscribe function
+func (manager *StateManager) SubscribeEvents(callback func(babyUID string, event Event)) func() {
+	unsubscribeC := make(chan bool, 1)
+
+	manager.subscribersMutex.Lock()
+	manager.eventSubscribers[&unsubscribeC] = callback
+	manager.subscribersMutex.Unlock()
+
+	return func() {
+		manager.subscribersMutex.Lock()
+		delete(manager.eventSubscribers, &unsubscribeC)
+		manager.subscribersMutex.Unlock()
+	}
+}
+
+// NotifyEvent - notifies event subscribers
+func (manager *StateManager) NotifyEvent(babyUID string, event Event) {
+	log.Debug().Str("baby_uid", babyUID).Str("event", event.Name).Interface("data", event.Data).Msg("Baby event")
+
+	manager.subscribersMutex.RLock()
+
+	for _, callback := range manager.eventSubscribers {
+		go callback(babyUID, event)
+	}
+
+	manager.subscribersMutex.RUnlock()
+}
+
 // GetBabyState - returns current state of a baby
 func (manager *StateManager) GetBabyState(babyUID string) *State {
 	manager.stateMutex.RLock()
@@ -5,4 +5,11 @@ import "time"
 const (
 	// AuthTokenTimelife - Time duration after which we assume auth token expired
 	AuthTokenTimelife = 60 * time.Minute
+
+	// UCTokenTimelife - Time duration after which we try to refresh user camera token
+	// Note: the token lives much longer, we just want to have a fresh one in case we go offline
+	UCTokenTimelife = 24 * time.Hour
+
+	// DefaultKeepaliveTimeout - Time duration without any message from the cam after which we consider the connection dead
+	DefaultKeepaliveTimeout = 60 * time.Second
 )
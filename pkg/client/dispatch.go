@@ -0,0 +1,134 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DispatchQueueSize - number of received messages buffered for the message handlers
+// Requests from the cam received while the buffer is full are answered right away, other messages are dropped
+const DispatchQueueSize = 256
+
+// slowHandlerThreshold - message handlers taking longer than this are reported
+const slowHandlerThreshold = time.Second
+
+// DispatchStats - message dispatch metrics of a connection
+type DispatchStats struct {
+	Dispatched   uint64        `json:"dispatched"`
+	Dropped      uint64        `json:"dropped"`
+	Overflowed   uint64        `json:"overflowed"` // requests answered by the reader as the queue was full
+	Queued       int           `json:"queued"`
+	TotalLatency time.Duration `json:"total_latency"` // time spent in message handlers
+	MaxLatency   time.Duration `json:"max_latency"`
+}
+
+// AvgLatency - average time spent in message handlers per message
+func (stats DispatchStats) AvgLatency() time.Duration {
+	if stats.Dispatched == 0 {
+		return 0
+	}
+
+	return stats.TotalLatency / time.Duration(stats.Dispatched)
+}
+
+type dispatcher struct {
+	queue chan *Message
+
+	mu    sync.Mutex
+	stats DispatchStats
+}
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{
+		queue: make(chan *Message, DispatchQueueSize),
+	}
+}
+
+// Dispatch - queues received message for the message handlers
+// Handlers are called one by one in the order of arrival, requests from the cam are answered before the message handlers are called
+// and awaited responses are resolved after the message handlers, so that a caller of Do observes the state the handlers produced.
+// Note: Handlers run on the dispatcher goroutine and must not wait for a response (call Do in a separate goroutine), it would not arrive until they return.
+//
+// Dispatch never blocks the caller (socket reader). When the queue is full, requests from the cam are still answered by their request handlers
+// right away, so that they are never left unanswered, but they skip the message handlers. Other messages are dropped (and not inspected),
+// awaited responses are still resolved.
+func (conn *WebsocketConnection) Dispatch(m *Message) {
+	select {
+	case conn.dispatcher.queue <- m:
+		return
+	default:
+	}
+
+	if *m.Type == Message_REQUEST && m.Request != nil {
+		conn.dispatcher.mu.Lock()
+		conn.dispatcher.stats.Overflowed++
+		conn.dispatcher.mu.Unlock()
+
+		log.Warn().Stringer("data", m).Msg("Message handlers are falling behind, answering request out of order")
+		conn.handleRequest(m.Request)
+		return
+	}
+
+	conn.dispatcher.mu.Lock()
+	conn.dispatcher.stats.Dropped++
+	dropped := conn.dispatcher.stats.Dropped
+	conn.dispatcher.mu.Unlock()
+
+	log.Warn().Uint64("dropped", dropped).Stringer("data", m).Msg("Message handlers are falling behind, dropping message")
+
+	if *m.Type == Message_RESPONSE && m.Response != nil {
+		conn.handleResponse(m.Response)
+	}
+}
+
+// DispatchStats - returns message dispatch metrics
+func (conn *WebsocketConnection) DispatchStats() DispatchStats {
+	conn.dispatcher.mu.Lock()
+	defer conn.dispatcher.mu.Unlock()
+
+	stats := conn.dispatcher.stats
+	stats.Queued = len(conn.dispatcher.queue)
+
+	return stats
+}
+
+// runDispatcher - calls message handlers for queued messages until the connection is closed
+func (conn *WebsocketConnection) runDispatcher() {
+	for {
+		select {
+		case <-conn.closedC:
+			return
+		case m := <-conn.dispatcher.queue:
+			// Inspection walks the whole message, keep it off the socket reader
+			if conn.Inspector != nil {
+				conn.Inspector.Inspect(m)
+			}
+
+			start := time.Now()
+			if *m.Type == Message_REQUEST && m.Request != nil {
+				conn.handleRequest(m.Request)
+			}
+
+			conn.notifyMessageHandlers(m)
+			latency := time.Since(start)
+
+			if *m.Type == Message_RESPONSE && m.Response != nil {
+				conn.handleResponse(m.Response)
+			}
+
+			conn.dispatcher.mu.Lock()
+			conn.dispatcher.stats.Dispatched++
+			conn.dispatcher.stats.TotalLatency += latency
+			if latency > conn.dispatcher.stats.MaxLatency {
+				conn.dispatcher.stats.MaxLatency = latency
+			}
+			conn.dispatcher.mu.Unlock()
+
+			if latency > slowHandlerThreshold {
+				log.Warn().Dur("latency", latency).Stringer("data", m).Msg("Slow message handler")
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+func newTestSensorMessage(value int32) *Message {
+	return &Message{
+		Type: Message_REQUEST.Enum(),
+		Request: &Request{
+			Id:   utils.ConstRefInt32(value),
+			Type: RequestType_PUT_SENSOR_DATA.Enum(),
+		},
+	}
+}
+
+func TestDispatchPreservesOrder(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+	defer conn.Close()
+
+	receivedC := make(chan int32, 100)
+	conn.RegisterMessageHandler(func(m *Message, _ *WebsocketConnection) {
+		receivedC <- m.Request.GetId()
+	})
+
+	for i := int32(1); i <= 100; i++ {
+		conn.Dispatch(newTestSensorMessage(i))
+	}
+
+	for i := int32(1); i <= 100; i++ {
+		select {
+		case id := <-receivedC:
+			require.Equal(t, i, id)
+		case <-time.After(time.Second):
+			t.Fatal("Message was not dispatched")
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		return conn.DispatchStats().Dispatched == 100
+	}, time.Second, time.Millisecond)
+}
+
+func TestDispatchDropsWhenFull(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+	defer conn.Close()
+
+	blockC := make(chan struct{})
+	conn.RegisterMessageHandler(func(m *Message, _ *WebsocketConnection) {
+		<-blockC
+	})
+
+	// First message is being handled, the rest fills the queue
+	for i := 0; i < DispatchQueueSize+11; i++ {
+		conn.Dispatch(&Message{Type: Message_KEEPALIVE.Enum()})
+
+		if i == 0 {
+			require.Eventually(t, func() bool {
+				return len(conn.dispatcher.queue) == 0
+			}, time.Second, time.Millisecond)
+		}
+	}
+
+	stats := conn.DispatchStats()
+	assert.Equal(t, uint64(10), stats.Dropped)
+	assert.Equal(t, DispatchQueueSize, stats.Queued)
+
+	close(blockC)
+}
+
+func TestDispatchAnswersRequestsWhenFull(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+	defer conn.Close()
+
+	blockC := make(chan struct{})
+	defer close(blockC)
+
+	conn.RegisterMessageHandler(func(m *Message, _ *WebsocketConnection) {
+		<-blockC
+	})
+
+	answeredC := make(chan int32, DispatchQueueSize+2)
+	conn.HandleRequest(RequestType_PUT_SENSOR_DATA, func(r *Request, _ *WebsocketConnection) *Response {
+		answeredC <- r.GetId()
+		return nil
+	})
+
+	// First request is being handled, the rest fills the queue
+	conn.Dispatch(newTestSensorMessage(0))
+	require.Equal(t, int32(0), <-answeredC)
+
+	for i := int32(1); i <= DispatchQueueSize; i++ {
+		conn.Dispatch(newTestSensorMessage(i))
+	}
+
+	// Reader is not blocked, the request is answered right away
+	conn.Dispatch(newTestSensorMessage(DispatchQueueSize + 1))
+
+	select {
+	case id := <-answeredC:
+		assert.Equal(t, int32(DispatchQueueSize+1), id)
+	case <-time.After(time.Second):
+		t.Fatal("Request was not answered")
+	}
+
+	stats := conn.DispatchStats()
+	assert.Equal(t, uint64(1), stats.Overflowed)
+	assert.Equal(t, uint64(0), stats.Dropped)
+}
+
+func TestDispatchResolvesResponseAfterHandlers(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+	defer conn.Close()
+
+	var handled int32
+	conn.RegisterMessageHandler(func(m *Message, _ *WebsocketConnection) {
+		time.Sleep(10 * time.Millisecond)
+		atomic.StoreInt32(&handled, 1)
+	})
+
+	go func() {
+		require.Eventually(t, func() bool {
+			conn.resHandlersMu.Lock()
+			defer conn.resHandlersMu.Unlock()
+			return len(conn.resHandlers) == 1
+		}, time.Second, time.Millisecond)
+
+		conn.Dispatch(newTestResponse(1, 200, ""))
+	}()
+
+	_, err := conn.Do(context.Background(), RequestType_GET_STATUS, &Request{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&handled))
+}
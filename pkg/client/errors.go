@@ -0,0 +1,37 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+const appConnectionLimitMessage = "Forbidden: Number of Mobile App connections above limit, declining connection"
+
+// ErrTimeout - no response received within the request context deadline
+var ErrTimeout = errors.New("Request timeout")
+
+// ErrConnectionClosed - connection was closed before the response arrived
+var ErrConnectionClosed = errors.New("Connection closed")
+
+// ErrAppConnectionLimit - cam declined the request because too many apps are connected to it
+// Matches the corresponding ErrStatus through errors.Is
+var ErrAppConnectionLimit = errors.New(appConnectionLimitMessage)
+
+// ErrStatus - cam responded with non-200 status code
+type ErrStatus struct {
+	Code    int32
+	Message string
+}
+
+func (err *ErrStatus) Error() string {
+	if err.Message != "" {
+		return err.Message
+	}
+
+	return fmt.Sprintf("Unexpected status code %v", err.Code)
+}
+
+// Is - allows matching well known statuses by errors.Is
+func (err *ErrStatus) Is(target error) bool {
+	return target == ErrAppConnectionLimit && err.Message == appConnectionLimitMessage
+}
@@ -0,0 +1,264 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// DiscoveryUnknownField - field with number not defined in the proto file
+	DiscoveryUnknownField = "unknown_field"
+
+	// DiscoveryUnknownEnum - enum field holding value not defined in the proto file
+	DiscoveryUnknownEnum = "unknown_enum"
+)
+
+// Discovery - part of a received message which is not covered by the proto file
+type Discovery struct {
+	Kind        string     `json:"kind"`
+	Path        string     `json:"path"`   // path of the containing message, ie. response.settings.streams
+	Number      int32      `json:"number"` // field number
+	EnumValue   *int32     `json:"enum_value,omitempty"`
+	RequestType string     `json:"request_type,omitempty"`
+	Raw         string     `json:"raw"` // hex encoded field as received (tag included)
+	Decoded     []RawField `json:"decoded,omitempty"`
+	Count       int        `json:"count"`
+	FirstSeen   time.Time  `json:"first_seen"`
+	LastSeen    time.Time  `json:"last_seen"`
+}
+
+func (discovery *Discovery) key() string {
+	enumValue := ""
+	if discovery.EnumValue != nil {
+		enumValue = fmt.Sprint(*discovery.EnumValue)
+	}
+
+	return fmt.Sprintf("%v|%v|%v|%v|%v", discovery.Kind, discovery.RequestType, discovery.Path, discovery.Number, enumValue)
+}
+
+// Inspector - collects discoveries of received messages into a deduplicated JSON report file
+// Report is rewritten whenever something new is discovered, occurrence counts are written on Flush
+type Inspector struct {
+	mu          sync.Mutex
+	filename    string
+	discoveries []*Discovery
+	index       map[string]*Discovery
+}
+
+// OpenInspector - creates inspector writing to given report file, discoveries already in the file are kept
+func OpenInspector(filename string) (*Inspector, error) {
+	inspector := &Inspector{
+		filename: filename,
+		index:    make(map[string]*Discovery),
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &inspector.discoveries); err != nil {
+			return nil, err
+		}
+
+		for _, discovery := range inspector.discoveries {
+			inspector.index[discovery.key()] = discovery
+		}
+	}
+
+	return inspector, nil
+}
+
+// Inspect - adds discoveries of the received message to the report
+func (inspector *Inspector) Inspect(m *Message) {
+	discoveries := InspectMessage(m)
+	if len(discoveries) == 0 {
+		return
+	}
+
+	inspector.mu.Lock()
+	defer inspector.mu.Unlock()
+
+	discovered := false
+	for _, discovery := range discoveries {
+		if existing, ok := inspector.index[discovery.key()]; ok {
+			existing.Count++
+			existing.LastSeen = discovery.LastSeen
+			continue
+		}
+
+		log.Warn().Str("kind", discovery.Kind).Str("path", discovery.Path).Int32("number", discovery.Number).Str("request_type", discovery.RequestType).Str("raw", discovery.Raw).Msg("Discovered part of the message not covered by the proto file")
+
+		d := discovery
+		inspector.discoveries = append(inspector.discoveries, &d)
+		inspector.index[d.key()] = &d
+		discovered = true
+	}
+
+	if discovered {
+		if err := inspector.write(); err != nil {
+			log.Error().Str("file", inspector.filename).Err(err).Msg("Unable to write inspector report")
+		}
+	}
+}
+
+// Discoveries - returns copy of all discoveries
+func (inspector *Inspector) Discoveries() []Discovery {
+	inspector.mu.Lock()
+	defer inspector.mu.Unlock()
+
+	discoveries := make([]Discovery, len(inspector.discoveries))
+	for i, discovery := range inspector.discoveries {
+		discoveries[i] = *discovery
+	}
+
+	return discoveries
+}
+
+// Flush - writes the report including current occurrence counts
+func (inspector *Inspector) Flush() error {
+	inspector.mu.Lock()
+	defer inspector.mu.Unlock()
+
+	return inspector.write()
+}
+
+func (inspector *Inspector) write() error {
+	sort.SliceStable(inspector.discoveries, func(i, j int) bool {
+		return inspector.discoveries[i].key() < inspector.discoveries[j].key()
+	})
+
+	data, err := json.MarshalIndent(inspector.discoveries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Written through temporary file, so that the report is never left half written
+	tmpFilename := inspector.filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFilename, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, inspector.filename)
+}
+
+// InspectMessage - walks the message and returns unknown fields and unknown enum values found in it
+func InspectMessage(m *Message) []Discovery {
+	requestType := ""
+	if m.Request != nil && m.Request.Type != nil {
+		requestType = m.Request.GetType().String()
+	} else if m.Response != nil && m.Response.RequestType != nil {
+		requestType = m.Response.GetRequestType().String()
+	}
+
+	now := time.Now()
+	discoveries := make([]Discovery, 0)
+
+	inspectMessage(m.ProtoReflect(), "message", func(discovery Discovery) {
+		discovery.RequestType = requestType
+		discovery.Count = 1
+		discovery.FirstSeen = now
+		discovery.LastSeen = now
+		discoveries = append(discoveries, discovery)
+	})
+
+	return discoveries
+}
+
+func inspectMessage(m protoreflect.Message, path string, report func(Discovery)) {
+	fields := m.Descriptor().Fields()
+
+	// Unknown fields, enum values out of range might end up here as well
+	for b := m.GetUnknown(); len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return
+		}
+
+		valueLen := protowire.ConsumeFieldValue(num, typ, b[n:])
+		if valueLen < 0 {
+			return
+		}
+
+		raw := b[:n+valueLen]
+		b = b[n+valueLen:]
+
+		if fd := fields.ByNumber(num); fd != nil && fd.Kind() == protoreflect.EnumKind && typ == protowire.VarintType {
+			v, _ := protowire.ConsumeVarint(raw[n:])
+			report(newUnknownEnumDiscovery(path, fd, protoreflect.EnumNumber(v)))
+			continue
+		}
+
+		discovery := Discovery{
+			Kind:   DiscoveryUnknownField,
+			Path:   path,
+			Number: int32(num),
+			Raw:    hex.EncodeToString(raw),
+		}
+
+		if decoded, err := DecodeRawFields(raw); err == nil {
+			discovery.Decoded = decoded
+		}
+
+		report(discovery)
+	}
+
+	// Known fields
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fieldPath := path + "." + string(fd.Name())
+
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				inspectValue(fd, list.Get(i), path, fieldPath, report)
+			}
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				inspectValue(fd.MapValue(), mv, path, fieldPath, report)
+				return true
+			})
+		default:
+			inspectValue(fd, v, path, fieldPath, report)
+		}
+
+		return true
+	})
+}
+
+func inspectValue(fd protoreflect.FieldDescriptor, v protoreflect.Value, path string, fieldPath string, report func(Discovery)) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		inspectMessage(v.Message(), fieldPath, report)
+	case protoreflect.EnumKind:
+		if fd.Enum().Values().ByNumber(v.Enum()) == nil {
+			report(newUnknownEnumDiscovery(path, fd, v.Enum()))
+		}
+	}
+}
+
+func newUnknownEnumDiscovery(path string, fd protoreflect.FieldDescriptor, value protoreflect.EnumNumber) Discovery {
+	enumValue := int32(value)
+
+	raw := protowire.AppendTag(nil, fd.Number(), protowire.VarintType)
+	raw = protowire.AppendVarint(raw, uint64(int64(enumValue)))
+
+	return Discovery{
+		Kind:      DiscoveryUnknownEnum,
+		Path:      path,
+		Number:    int32(fd.Number()),
+		EnumValue: &enumValue,
+		Raw:       hex.EncodeToString(raw),
+	}
+}
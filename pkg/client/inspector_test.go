@@ -0,0 +1,111 @@
+package client_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gregory-m/nanit/pkg/client"
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+// newTestSettingsMessage - returns received PUT_SETTINGS request with unknown field 99 in settings
+// and unknown stream identifier 7 (streams are field 8 of settings)
+func newTestSettingsMessage(t *testing.T) *client.Message {
+	stream := protowire.AppendTag(nil, 1, protowire.VarintType)
+	stream = protowire.AppendVarint(stream, 7)
+
+	settings := protowire.AppendTag(nil, 99, protowire.BytesType)
+	settings = protowire.AppendString(settings, "new feature")
+	settings = protowire.AppendTag(settings, 8, protowire.BytesType)
+	settings = protowire.AppendBytes(settings, stream)
+
+	request, err := proto.Marshal(&client.Request{
+		Id:   utils.ConstRefInt32(1),
+		Type: client.RequestType_PUT_SETTINGS.Enum(),
+	})
+	require.NoError(t, err)
+
+	// Settings are field 5 of the request, request is field 2 of the message
+	request = protowire.AppendTag(request, 5, protowire.BytesType)
+	request = protowire.AppendBytes(request, settings)
+
+	data := protowire.AppendTag(nil, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, uint64(client.Message_REQUEST))
+	data = protowire.AppendTag(data, 2, protowire.BytesType)
+	data = protowire.AppendBytes(data, request)
+
+	received := &client.Message{}
+	require.NoError(t, proto.UnmarshalOptions{AllowPartial: true}.Unmarshal(data, received))
+
+	return received
+}
+
+func TestInspectMessage(t *testing.T) {
+	discoveries := client.InspectMessage(newTestSettingsMessage(t))
+	require.Len(t, discoveries, 2)
+
+	assert.Equal(t, client.DiscoveryUnknownField, discoveries[0].Kind)
+	assert.Equal(t, "message.request.settings", discoveries[0].Path)
+	assert.Equal(t, int32(99), discoveries[0].Number)
+	assert.Equal(t, "PUT_SETTINGS", discoveries[0].RequestType)
+	assert.Equal(t, "new feature", discoveries[0].Decoded[0].Value)
+
+	assert.Equal(t, client.DiscoveryUnknownEnum, discoveries[1].Kind)
+	assert.Equal(t, "message.request.settings.streams", discoveries[1].Path)
+	assert.Equal(t, int32(1), discoveries[1].Number)
+	assert.Equal(t, int32(7), *discoveries[1].EnumValue)
+	assert.Equal(t, "0807", discoveries[1].Raw)
+}
+
+func TestInspectorReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspector")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "report.json")
+
+	inspector, err := client.OpenInspector(filename)
+	require.NoError(t, err)
+
+	inspector.Inspect(newTestSettingsMessage(t))
+	inspector.Inspect(newTestSettingsMessage(t))
+	inspector.Inspect(&client.Message{Type: client.Message_KEEPALIVE.Enum()})
+	require.NoError(t, inspector.Flush())
+
+	// Reopened inspector continues with the same report
+	reopened, err := client.OpenInspector(filename)
+	require.NoError(t, err)
+
+	discoveries := reopened.Discoveries()
+	require.Len(t, discoveries, 2)
+	for _, discovery := range discoveries {
+		assert.Equal(t, 2, discovery.Count)
+	}
+}
+
+func TestInspectDispatched(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspector")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	inspector, err := client.OpenInspector(filepath.Join(dir, "report.json"))
+	require.NoError(t, err)
+
+	conn := client.NewWebsocketConnection(nil)
+	defer conn.Close()
+	conn.Inspector = inspector
+
+	conn.Dispatch(newTestSettingsMessage(t))
+
+	assert.Eventually(t, func() bool {
+		return len(inspector.Discoveries()) == 2
+	}, time.Second, time.Millisecond)
+}
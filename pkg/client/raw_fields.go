@@ -0,0 +1,111 @@
+package client
+
+import (
+	"encoding/hex"
+	"errors"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// RawField - schema-less representation of a protobuf field
+// Used for payloads we don't have in the proto file yet
+type RawField struct {
+	Number int32       `json:"number"`
+	Type   string      `json:"type"`
+	Value  interface{} `json:"value"` // uint64 | string | []RawField
+}
+
+var errMalformedRawFields = errors.New("Malformed protobuf data")
+
+// GetUnknownFields - decodes fields of a message which are not covered by the proto file
+func GetUnknownFields(m proto.Message) []RawField {
+	fields, err := DecodeRawFields(m.ProtoReflect().GetUnknown())
+	if err != nil {
+		return nil
+	}
+
+	return fields
+}
+
+// DecodeRawFields - decodes protobuf wire format without knowing the schema
+// Length-delimited fields are guessed to be either a printable string, a nested message or raw bytes (hex)
+func DecodeRawFields(b []byte) ([]RawField, error) {
+	fields := make([]RawField, 0)
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, errMalformedRawFields
+		}
+
+		b = b[n:]
+		field := RawField{Number: int32(num)}
+
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, errMalformedRawFields
+			}
+
+			field.Type, field.Value, b = "varint", v, b[n:]
+
+		case protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(b)
+			if n < 0 {
+				return nil, errMalformedRawFields
+			}
+
+			field.Type, field.Value, b = "fixed32", uint64(v), b[n:]
+
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return nil, errMalformedRawFields
+			}
+
+			field.Type, field.Value, b = "fixed64", v, b[n:]
+
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, errMalformedRawFields
+			}
+
+			b = b[n:]
+
+			if isPrintable(v) {
+				field.Type, field.Value = "string", string(v)
+			} else if nested, err := DecodeRawFields(v); err == nil && len(nested) > 0 {
+				field.Type, field.Value = "message", nested
+			} else {
+				field.Type, field.Value = "bytes", hex.EncodeToString(v)
+			}
+
+		default:
+			// Groups are deprecated, we don't expect them
+			return nil, errMalformedRawFields
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func isPrintable(b []byte) bool {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return false
+	}
+
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,45 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/gregory-m/nanit/pkg/client"
+)
+
+func TestDecodeRawFields(t *testing.T) {
+	nested := protowire.AppendTag(nil, 1, protowire.VarintType)
+	nested = protowire.AppendVarint(nested, 7)
+
+	b := protowire.AppendTag(nil, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 150)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, "lullaby")
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendBytes(b, nested)
+
+	fields, err := client.DecodeRawFields(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []client.RawField{
+		{Number: 1, Type: "varint", Value: uint64(150)},
+		{Number: 2, Type: "string", Value: "lullaby"},
+		{Number: 3, Type: "message", Value: []client.RawField{{Number: 1, Type: "varint", Value: uint64(7)}}},
+	}, fields)
+}
+
+func TestDecodeRawFieldsMalformed(t *testing.T) {
+	_, err := client.DecodeRawFields([]byte{0x08})
+	assert.Error(t, err)
+}
+
+func TestGetUnknownFields(t *testing.T) {
+	unknown := protowire.AppendTag(nil, 99, protowire.BytesType)
+	unknown = protowire.AppendString(unknown, "new field")
+
+	res := &client.Response{}
+	res.ProtoReflect().SetUnknown(unknown)
+
+	assert.Equal(t, []client.RawField{{Number: 99, Type: "string", Value: "new field"}}, client.GetUnknownFields(res))
+}
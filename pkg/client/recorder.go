@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// RecordSent - message sent by us
+	RecordSent = "sent"
+
+	// RecordReceived - message received from the cam
+	RecordReceived = "received"
+)
+
+// RecordedMessage - single line of websocket traffic recording
+// Message is kept in readable form, Data holds the original bytes so that fields missing in the proto file are not lost
+type RecordedMessage struct {
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+	Data      []byte          `json:"data"`
+}
+
+// Recorder - writes websocket traffic to JSONL stream
+type Recorder struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewRecorder - constructor
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// OpenRecorder - creates recorder appending to given file
+func OpenRecorder(filename string) (*Recorder, error) {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := NewRecorder(f)
+	recorder.closer = f
+
+	return recorder, nil
+}
+
+// Record - writes message with its raw data
+func (recorder *Recorder) Record(direction string, m *Message, data []byte) {
+	readable, err := protojson.MarshalOptions{AllowPartial: true}.Marshal(m)
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to convert message to JSON for recording")
+		readable = []byte("null")
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	err = recorder.enc.Encode(RecordedMessage{
+		Time:      time.Now(),
+		Direction: direction,
+		Message:   readable,
+		Data:      data,
+	})
+
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to record message")
+	}
+}
+
+// Close - closes underlying file (if opened by OpenRecorder)
+func (recorder *Recorder) Close() error {
+	if recorder.closer != nil {
+		return recorder.closer.Close()
+	}
+
+	return nil
+}
+
+// Decode - returns recorded message, preferring the original bytes
+func (rm RecordedMessage) Decode() (*Message, error) {
+	m := &Message{}
+
+	if len(rm.Data) > 0 {
+		return m, proto.Unmarshal(rm.Data, m)
+	} else if len(rm.Message) > 0 {
+		return m, protojson.UnmarshalOptions{AllowPartial: true, DiscardUnknown: true}.Unmarshal(rm.Message, m)
+	}
+
+	return nil, errors.New("Recorded message is empty")
+}
+
+// ReadRecording - reads all messages of the recording
+func ReadRecording(r io.Reader) ([]RecordedMessage, error) {
+	messages := make([]RecordedMessage, 0)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rm RecordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &rm); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, rm)
+	}
+
+	return messages, scanner.Err()
+}
+
+// Replay - feeds messages received in the recording to the connection as if they came from the cam
+// Messages are handled synchronously in the recorded order. Sent messages are skipped, handlers are expected to send them again.
+func Replay(r io.Reader, conn *WebsocketConnection) error {
+	messages, err := ReadRecording(r)
+	if err != nil {
+		return err
+	}
+
+	for i, rm := range messages {
+		if rm.Direction != RecordReceived {
+			continue
+		}
+
+		m, err := rm.Decode()
+		if err != nil {
+			log.Warn().Int("line", i+1).Err(err).Msg("Skipping malformed recorded message")
+			continue
+		}
+
+		log.Debug().Int("line", i+1).Stringer("data", m).Msg("Replaying message")
+		conn.handleMessage(m)
+	}
+
+	return nil
+}
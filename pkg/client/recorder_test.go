@@ -0,0 +1,78 @@
+package client_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gregory-m/nanit/pkg/client"
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := client.NewRecorder(&buf)
+
+	sent := &client.Message{
+		Type: client.Message_REQUEST.Enum(),
+		Request: &client.Request{
+			Id:   utils.ConstRefInt32(1),
+			Type: client.RequestType_GET_STATUS.Enum(),
+		},
+	}
+
+	received := &client.Message{
+		Type: client.Message_RESPONSE.Enum(),
+		Response: &client.Response{
+			RequestId:   utils.ConstRefInt32(1),
+			RequestType: client.RequestType_GET_STATUS.Enum(),
+			StatusCode:  utils.ConstRefInt32(200),
+			Status: &client.Status{
+				CurrentVersion: utils.ConstRefStr("1.2.3"),
+			},
+		},
+	}
+
+	// Field unknown to the proto file has to survive the recording
+	unknown := protowire.AppendTag(nil, 99, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 42)
+	received.Response.ProtoReflect().SetUnknown(unknown)
+
+	for direction, m := range map[string]*client.Message{client.RecordSent: sent, client.RecordReceived: received} {
+		data, err := proto.Marshal(m)
+		require.NoError(t, err)
+		recorder.Record(direction, m, data)
+	}
+
+	conn := client.NewWebsocketConnection(nil)
+
+	var replayed []*client.Message
+	conn.RegisterMessageHandler(func(m *client.Message, _ *client.WebsocketConnection) {
+		replayed = append(replayed, m)
+	})
+
+	require.NoError(t, client.Replay(&buf, conn))
+	require.Len(t, replayed, 1)
+
+	assert.Equal(t, "1.2.3", replayed[0].Response.Status.GetCurrentVersion())
+	assert.Equal(t, []client.RawField{{Number: 99, Type: "varint", Value: uint64(42)}}, client.GetUnknownFields(replayed[0].Response))
+}
+
+func TestReplayFromReadableMessage(t *testing.T) {
+	recording := `{"time":"2021-01-01T00:00:00Z","direction":"received","message":{"type":"REQUEST","request":{"id":1,"type":"PUT_STATUS","status":{"currentVersion":"1.2.3"}}}}` + "\n"
+
+	conn := client.NewWebsocketConnection(nil)
+
+	var replayed []*client.Message
+	conn.RegisterMessageHandler(func(m *client.Message, _ *client.WebsocketConnection) {
+		replayed = append(replayed, m)
+	})
+
+	require.NoError(t, client.Replay(bytes.NewBufferString(recording), conn))
+	require.Len(t, replayed, 1)
+	assert.Equal(t, "1.2.3", replayed[0].Request.Status.GetCurrentVersion())
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+// WebsocketRequestHandler - handler of a request initiated by the cam
+// Returned response is sent back to the cam, nil is acknowledged with 200 status
+type WebsocketRequestHandler func(*Request, *WebsocketConnection) *Response
+
+// HandleRequest - registers handler for requests of given type sent by the cam, replaces previously registered one
+// Requests without handler are logged and acknowledged with 200 status
+func (conn *WebsocketConnection) HandleRequest(reqType RequestType, handler WebsocketRequestHandler) {
+	conn.reqHandlersMu.Lock()
+	conn.reqHandlers[reqType] = handler
+	conn.reqHandlersMu.Unlock()
+}
+
+// handleRequest - passes request to the registered handler and responds to the cam
+func (conn *WebsocketConnection) handleRequest(r *Request) {
+	reqType := r.GetType()
+
+	conn.reqHandlersMu.Lock()
+	handler, ok := conn.reqHandlers[reqType]
+	reported := conn.unhandledReqTypes[reqType]
+	if !ok {
+		conn.unhandledReqTypes[reqType] = true
+	}
+	conn.reqHandlersMu.Unlock()
+
+	var res *Response
+	if ok {
+		res = handler(r, conn)
+	} else if !reported {
+		log.Warn().Stringer("type", reqType).Stringer("data", r).Msg("No handler for request from the cam, acknowledging")
+	} else {
+		log.Debug().Stringer("type", reqType).Msg("No handler for request from the cam, acknowledging")
+	}
+
+	if res == nil {
+		res = &Response{}
+	}
+
+	res.RequestId = utils.ConstRefInt32(r.GetId())
+	res.RequestType = RequestType(reqType).Enum()
+	if res.StatusCode == nil {
+		res.StatusCode = utils.ConstRefInt32(200)
+	}
+
+	conn.SendMessage(&Message{
+		Type:     Message_Type(Message_RESPONSE).Enum(),
+		Response: res,
+	})
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+func newTestRequest(id int32, reqType RequestType) *Message {
+	return &Message{
+		Type: Message_REQUEST.Enum(),
+		Request: &Request{
+			Id:   utils.ConstRefInt32(id),
+			Type: reqType.Enum(),
+		},
+	}
+}
+
+func readTestResponse(t *testing.T, socket Socket) *Response {
+	data, err := socket.ReadMessage()
+	require.NoError(t, err)
+
+	m := &Message{}
+	require.NoError(t, proto.Unmarshal(data, m))
+	require.Equal(t, Message_RESPONSE, m.GetType())
+
+	return m.Response
+}
+
+func TestHandleRequestResponse(t *testing.T) {
+	local, remote := NewMemorySocketPair()
+	conn := NewWebsocketConnection(local)
+
+	conn.HandleRequest(RequestType_PUT_HEALTH, func(r *Request, _ *WebsocketConnection) *Response {
+		return &Response{
+			StatusCode:    utils.ConstRefInt32(400),
+			StatusMessage: utils.ConstRefStr("Bad health"),
+		}
+	})
+
+	conn.handleMessage(newTestRequest(7, RequestType_PUT_HEALTH))
+
+	res := readTestResponse(t, remote)
+	assert.Equal(t, int32(7), res.GetRequestId())
+	assert.Equal(t, RequestType_PUT_HEALTH, res.GetRequestType())
+	assert.Equal(t, int32(400), res.GetStatusCode())
+	assert.Equal(t, "Bad health", res.GetStatusMessage())
+}
+
+func TestHandleRequestAck(t *testing.T) {
+	local, remote := NewMemorySocketPair()
+	conn := NewWebsocketConnection(local)
+
+	handled := false
+	conn.HandleRequest(RequestType_PUT_SENSOR_DATA, func(r *Request, _ *WebsocketConnection) *Response {
+		handled = true
+		return nil
+	})
+
+	conn.handleMessage(newTestRequest(3, RequestType_PUT_SENSOR_DATA))
+	assert.True(t, handled)
+
+	res := readTestResponse(t, remote)
+	assert.Equal(t, int32(3), res.GetRequestId())
+	assert.Equal(t, RequestType_PUT_SENSOR_DATA, res.GetRequestType())
+	assert.Equal(t, int32(200), res.GetStatusCode())
+
+	// Requests without a handler are acknowledged as well
+	conn.handleMessage(newTestRequest(4, RequestType_PUT_STING_ALERT))
+
+	res = readTestResponse(t, remote)
+	assert.Equal(t, int32(4), res.GetRequestId())
+	assert.Equal(t, RequestType_PUT_STING_ALERT, res.GetRequestType())
+	assert.Equal(t, int32(200), res.GetStatusCode())
+}
+
+func TestHandleRequestNotifiesMessageHandlers(t *testing.T) {
+	local, remote := NewMemorySocketPair()
+	conn := NewWebsocketConnection(local)
+
+	var received *Message
+	conn.RegisterMessageHandler(func(m *Message, _ *WebsocketConnection) {
+		received = m
+	})
+
+	conn.handleMessage(newTestRequest(1, RequestType_PUT_KEEP_ALIVE))
+	readTestResponse(t, remote)
+
+	require.NotNil(t, received)
+	assert.Equal(t, RequestType_PUT_KEEP_ALIVE, received.Request.GetType())
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+// GetSensorData - returns current readings of all sensors
+func (conn *WebsocketConnection) GetSensorData(ctx context.Context) ([]*SensorData, error) {
+	res, err := conn.Do(ctx, RequestType_GET_SENSOR_DATA, &Request{
+		GetSensorData: &GetSensorData{
+			All: utils.ConstRefBool(true),
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res.SensorData, nil
+}
+
+// GetSettings - returns current cam settings
+func (conn *WebsocketConnection) GetSettings(ctx context.Context) (*Settings, error) {
+	res, err := conn.Do(ctx, RequestType_GET_SETTINGS, &Request{})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Settings, nil
+}
+
+// PutSettings - updates cam settings, returns the settings as confirmed by the cam (if it sends them)
+func (conn *WebsocketConnection) PutSettings(ctx context.Context, settings *Settings) (*Settings, error) {
+	res, err := conn.Do(ctx, RequestType_PUT_SETTINGS, &Request{
+		Settings: settings,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Settings, nil
+}
+
+// GetStatus - returns cam status (firmware, connection, mounting mode)
+func (conn *WebsocketConnection) GetStatus(ctx context.Context) (*Status, error) {
+	res, err := conn.Do(ctx, RequestType_GET_STATUS, &Request{
+		GetStatus_: &GetStatus{
+			All: utils.ConstRefBool(true),
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Status, nil
+}
+
+// PutControl - controls the cam (ie. night light)
+func (conn *WebsocketConnection) PutControl(ctx context.Context, control *Control) error {
+	_, err := conn.Do(ctx, RequestType_PUT_CONTROL, &Request{
+		Control: control,
+	})
+
+	return err
+}
+
+// PutStreaming - asks the cam to start / stop pushing the stream to given RTMP URL
+func (conn *WebsocketConnection) PutStreaming(ctx context.Context, streaming *Streaming) error {
+	_, err := conn.Do(ctx, RequestType_PUT_STREAMING, &Request{
+		Streaming: streaming,
+	})
+
+	return err
+}
+
+// PutPlayback - starts / stops the sound machine
+func (conn *WebsocketConnection) PutPlayback(ctx context.Context, playback *Playback) error {
+	_, err := conn.Do(ctx, RequestType_PUT_PLAYBACK, &Request{
+		Playback: playback,
+	})
+
+	return err
+}
+
+// GetLogs - asks the cam to upload its logs to given URL, the upload happens asynchronously
+func (conn *WebsocketConnection) GetLogs(ctx context.Context, url string) error {
+	_, err := conn.Do(ctx, RequestType_GET_LOGS, &Request{
+		GetLogs: &GetLogs{
+			Url: utils.ConstRefStr(url),
+		},
+	})
+
+	return err
+}
+
+// Following responses carry payloads not described in the proto file (see docs/developer-notes.md#requests-with-unknown-payload),
+// they are returned as raw fields decoded schema-less until the payload is reverse engineered
+
+// RawSoundtracks - payload of GET_SOUNDTRACKS response (list of sound machine tracks)
+type RawSoundtracks []RawField
+
+// RawBandwidth - payload of GET_BANDWIDTH response
+type RawBandwidth []RawField
+
+// RawNetworkStatus - payload of GET_STATUS_NETWORK response (network the cam is connected to)
+type RawNetworkStatus []RawField
+
+// RawNetworkList - payload of GET_LIST_NETWORKS response (networks visible by the cam)
+type RawNetworkList []RawField
+
+// GetSoundtracks - returns list of sound machine tracks
+func (conn *WebsocketConnection) GetSoundtracks(ctx context.Context) (RawSoundtracks, error) {
+	res, err := conn.Do(ctx, RequestType_GET_SOUNDTRACKS, &Request{})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetUnknownFields(res), nil
+}
+
+// GetBandwidth - returns bandwidth info
+func (conn *WebsocketConnection) GetBandwidth(ctx context.Context) (RawBandwidth, error) {
+	res, err := conn.Do(ctx, RequestType_GET_BANDWIDTH, &Request{})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetUnknownFields(res), nil
+}
+
+// GetStatusNetwork - returns status of the network the cam is connected to
+func (conn *WebsocketConnection) GetStatusNetwork(ctx context.Context) (RawNetworkStatus, error) {
+	res, err := conn.Do(ctx, RequestType_GET_STATUS_NETWORK, &Request{})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetUnknownFields(res), nil
+}
+
+// GetListNetworks - returns networks visible by the cam
+func (conn *WebsocketConnection) GetListNetworks(ctx context.Context) (RawNetworkList, error) {
+	res, err := conn.Do(ctx, RequestType_GET_LIST_NETWORKS, &Request{})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetUnknownFields(res), nil
+}
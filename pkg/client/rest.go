@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -19,6 +20,8 @@ import (
 
 var myClient = &http.Client{Timeout: 10 * time.Second}
 var ErrExpiredRefreshToken = errors.New("Refresh token has expired. Relogin required.")
+var errAuthorizationFailed = errors.New("Unable to make request due failed authorization (2 attempts)")
+var errInvalidUCToken = errors.New("Server responded with invalid user camera token")
 
 type MFARequiredError struct {
 	MFAToken string
@@ -57,6 +60,10 @@ type messagesResponsePayload struct {
 	Messages []message.Message `json:"messages"`
 }
 
+type ucTokenResponsePayload struct {
+	Token string `json:"token"`
+}
+
 // ------------------------------------------
 
 // NanitClient - client context
@@ -66,32 +73,55 @@ type NanitClient struct {
 }
 
 // MaybeAuthorize - Performs authorization if we don't have token or we assume it is expired
-func (c *NanitClient) MaybeAuthorize(force bool) {
-	if force || c.SessionStore.Session.AuthToken == "" || time.Since(c.SessionStore.Session.AuthTime) > AuthTokenTimelife {
-		c.Authorize()
+func (c *NanitClient) MaybeAuthorize(force bool) error {
+	var expired bool
+	c.SessionStore.View(func(s *session.Session) {
+		expired = s.AuthToken == "" || time.Since(s.AuthTime) > AuthTokenTimelife
+	})
+
+	if force || expired {
+		return c.Authorize()
 	}
+
+	return nil
 }
 
-// Authorize - performs authorization attempt, panics if it fails
-func (c *NanitClient) Authorize() {
-	if len(c.SessionStore.Session.RefreshToken) == 0 {
-		c.SessionStore.Session.RefreshToken = c.RefreshToken
+// Authorize - performs authorization attempt
+func (c *NanitClient) Authorize() error {
+	var refreshToken string
+	c.SessionStore.View(func(s *session.Session) {
+		refreshToken = s.RefreshToken
+	})
+
+	if len(refreshToken) == 0 && len(c.RefreshToken) > 0 {
+		refreshToken = c.RefreshToken
+		c.SessionStore.Update(func(s *session.Session) {
+			s.RefreshToken = refreshToken
+		})
 	}
 
-	if len(c.SessionStore.Session.RefreshToken) > 0 {
+	if len(refreshToken) > 0 {
 		err := c.RenewSession() // We have a refresh token, so we'll use that to extend our session
 		if err != nil {
-			log.Fatal().Err(err).Msg("Error occurred while trying to refresh the session")
+			log.Error().Err(err).Msg("Error occurred while trying to refresh the session")
+			return err
 		}
 	}
+
+	return nil
 }
 
 // Renews an existing session using a valid refresh token
 // If the refresh token has also expired, we need to perform a full re-login
 func (c *NanitClient) RenewSession() error {
-	log.Debug().Str("refresh_token", utils.AnonymizeToken(c.SessionStore.Session.RefreshToken, 4)).Msg("Renewing Session")
+	var refreshToken string
+	c.SessionStore.View(func(s *session.Session) {
+		refreshToken = s.RefreshToken
+	})
+
+	log.Debug().Str("refresh_token", utils.AnonymizeToken(refreshToken, 4)).Msg("Renewing Session")
 	requestBody, requestBodyErr := json.Marshal(map[string]string{
-		"refresh_token": c.SessionStore.Session.RefreshToken,
+		"refresh_token": refreshToken,
 	})
 
 	if requestBodyErr != nil {
@@ -100,7 +130,7 @@ func (c *NanitClient) RenewSession() error {
 
 	r, clientErr := myClient.Post("https://api.nanit.com/tokens/refresh", "application/json", bytes.NewBuffer(requestBody))
 	if clientErr != nil {
-		log.Fatal().Err(clientErr).Msg("Unable to renew session")
+		return fmt.Errorf("Unable to renew session: %w", clientErr)
 	}
 
 	defer r.Body.Close()
@@ -108,22 +138,23 @@ func (c *NanitClient) RenewSession() error {
 		log.Warn().Msg("Server responded with code 404. This typically means your refresh token has expired.")
 		return ErrExpiredRefreshToken
 	} else if r.StatusCode > 299 || r.StatusCode < 200 {
-		log.Fatal().Int("code", r.StatusCode).Msg("Server responded with an error")
+		return fmt.Errorf("Unable to renew session, server responded with unexpected status code %d", r.StatusCode)
 	}
 
 	authResponse := new(authResponsePayload)
 
 	jsonErr := json.NewDecoder(r.Body).Decode(authResponse)
 	if jsonErr != nil {
-		log.Fatal().Err(jsonErr).Msg("Unable to decode response")
+		return fmt.Errorf("Unable to decode session renewal response: %w", jsonErr)
 	}
 
 	log.Info().Str("token", utils.AnonymizeToken(authResponse.AccessToken, 4)).Msg("Authorized")
 	log.Info().Str("refresh_token", utils.AnonymizeToken(authResponse.RefreshToken, 4)).Msg("Retreived")
-	c.SessionStore.Session.AuthToken = authResponse.AccessToken
-	c.SessionStore.Session.RefreshToken = authResponse.RefreshToken
-	c.SessionStore.Session.AuthTime = time.Now()
-	c.SessionStore.Save()
+	c.SessionStore.Update(func(s *session.Session) {
+		s.AuthToken = authResponse.AccessToken
+		s.RefreshToken = authResponse.RefreshToken
+		s.AuthTime = time.Now()
+	})
 
 	return nil
 }
@@ -186,43 +217,61 @@ func (c *NanitClient) Login(authReq *AuthRequestPayload) (accessToken string, re
 	return authResponse.AccessToken, authResponse.RefreshToken, nil
 }
 
-// FetchAuthorized - makes authorized http request
-func (c *NanitClient) FetchAuthorized(req *http.Request, data interface{}) {
+// FetchAuthorized - makes authorized http request, reauthorizes once if the token is rejected
+func (c *NanitClient) FetchAuthorized(req *http.Request, data interface{}) error {
+	return c.fetchAuthorized(req, data, "")
+}
+
+// fetchAuthorized - makes authorized http request with the auth token prefixed by the scheme (ie. "Bearer " for focus endpoints)
+func (c *NanitClient) fetchAuthorized(req *http.Request, data interface{}, authScheme string) error {
 	for i := 0; i < 2; i++ {
-		if c.SessionStore.Session.AuthToken != "" {
-			req.Header.Set("Authorization", c.SessionStore.Session.AuthToken)
+		if authToken := c.GetAuthToken(); authToken != "" {
+			req.Header.Set("Authorization", authScheme+authToken)
 
 			res, clientErr := myClient.Do(req)
 			if clientErr != nil {
-				log.Fatal().Err(clientErr).Msg("HTTP request failed")
+				return fmt.Errorf("HTTP request failed: %w", clientErr)
 			}
 
-			defer res.Body.Close()
-
 			if res.StatusCode != 401 {
+				defer res.Body.Close()
+
 				if res.StatusCode != 200 {
-					log.Fatal().Int("code", res.StatusCode).Msg("Server responded with unexpected status code")
+					return fmt.Errorf("Server responded with unexpected status code %d", res.StatusCode)
 				}
 
 				jsonErr := json.NewDecoder(res.Body).Decode(data)
 				if jsonErr != nil {
-					log.Fatal().Err(jsonErr).Msg("Unable to decode response")
+					return fmt.Errorf("Unable to decode response: %w", jsonErr)
 				}
 
-				return
+				return nil
 			}
 
+			res.Body.Close()
 			log.Info().Msg("Token might be expired. Will try to re-authenticate.")
 		}
 
-		c.Authorize()
+		if err := c.Authorize(); err != nil {
+			return err
+		}
 	}
 
-	log.Fatal().Msg("Unable to make request due failed authorization (2 attempts).")
+	return errAuthorizationFailed
+}
+
+// GetAuthToken - returns current auth token from the session
+func (c *NanitClient) GetAuthToken() string {
+	var authToken string
+	c.SessionStore.View(func(s *session.Session) {
+		authToken = s.AuthToken
+	})
+
+	return authToken
 }
 
 // FetchBabies - fetches baby list
-func (c *NanitClient) FetchBabies() []baby.Baby {
+func (c *NanitClient) FetchBabies() ([]baby.Baby, error) {
 	log.Info().Msg("Fetching babies list")
 	req, reqErr := http.NewRequest("GET", "https://api.nanit.com/babies", nil)
 
@@ -231,15 +280,19 @@ func (c *NanitClient) FetchBabies() []baby.Baby {
 	}
 
 	data := new(babiesResponsePayload)
-	c.FetchAuthorized(req, data)
+	if err := c.FetchAuthorized(req, data); err != nil {
+		return nil, fmt.Errorf("Unable to fetch babies: %w", err)
+	}
+
+	c.SessionStore.Update(func(s *session.Session) {
+		s.Babies = data.Babies
+	})
 
-	c.SessionStore.Session.Babies = data.Babies
-	c.SessionStore.Save()
-	return data.Babies
+	return data.Babies, nil
 }
 
 // FetchMessages - fetches message list
-func (c *NanitClient) FetchMessages(babyUID string, limit int) []message.Message {
+func (c *NanitClient) FetchMessages(babyUID string, limit int) ([]message.Message, error) {
 	req, reqErr := http.NewRequest("GET", fmt.Sprintf("https://api.nanit.com/babies/%s/messages?limit=%d", babyUID, limit), nil)
 
 	if reqErr != nil {
@@ -247,29 +300,45 @@ func (c *NanitClient) FetchMessages(babyUID string, limit int) []message.Message
 	}
 
 	data := new(messagesResponsePayload)
-	c.FetchAuthorized(req, data)
+	if err := c.FetchAuthorized(req, data); err != nil {
+		return nil, fmt.Errorf("Unable to fetch messages: %w", err)
+	}
 
-	return data.Messages
+	return data.Messages, nil
 }
 
 // EnsureBabies - fetches baby list if not fetched already
-func (c *NanitClient) EnsureBabies() []baby.Baby {
-	if len(c.SessionStore.Session.Babies) == 0 {
-		return c.FetchBabies()
+func (c *NanitClient) EnsureBabies() ([]baby.Baby, error) {
+	if babies := c.GetBabies(); len(babies) > 0 {
+		return babies, nil
 	}
 
-	return c.SessionStore.Session.Babies
+	return c.FetchBabies()
+}
+
+// GetBabies - returns baby list stored in the session
+func (c *NanitClient) GetBabies() []baby.Baby {
+	var babies []baby.Baby
+	c.SessionStore.View(func(s *session.Session) {
+		babies = s.Babies
+	})
+
+	return babies
 }
 
 // FetchNewMessages - fetches 10 newest messages, ignores any messages which were already fetched or which are older than 5 minutes
-func (c *NanitClient) FetchNewMessages(babyUID string, defaultMessageTimeout time.Duration) []message.Message {
-	fetchedMessages := c.FetchMessages(babyUID, 10)
+func (c *NanitClient) FetchNewMessages(babyUID string, defaultMessageTimeout time.Duration) ([]message.Message, error) {
+	fetchedMessages, err := c.FetchMessages(babyUID, 10)
+	if err != nil {
+		return nil, err
+	}
+
 	newMessages := make([]message.Message, 0)
 
 	// return empty [] if there are no fetchedMessages
 	if len(fetchedMessages) == 0 {
 		log.Debug().Msg("No messages fetched")
-		return newMessages
+		return newMessages, nil
 	}
 
 	// sort fetechedMessages starting with most recent
@@ -277,7 +346,11 @@ func (c *NanitClient) FetchNewMessages(babyUID string, defaultMessageTimeout tim
 		return fetchedMessages[i].Time.Time().After(fetchedMessages[j].Time.Time())
 	})
 
-	lastSeenMessageTime := c.SessionStore.Session.LastSeenMessageTime
+	var lastSeenMessageTime time.Time
+	c.SessionStore.View(func(s *session.Session) {
+		lastSeenMessageTime = s.LastSeenMessageTime
+	})
+
 	messageTimeoutTime := lastSeenMessageTime
 	log.Debug().Msgf("Last seen message time was %s", lastSeenMessageTime)
 
@@ -289,8 +362,9 @@ func (c *NanitClient) FetchNewMessages(babyUID string, defaultMessageTimeout tim
 	// lastSeenMessageTime is older than most recent fetchedMessage, or is unset
 	if lastSeenMessageTime.Before(fetchedMessages[0].Time.Time()) {
 		lastSeenMessageTime = fetchedMessages[0].Time.Time()
-		c.SessionStore.Session.LastSeenMessageTime = lastSeenMessageTime
-		c.SessionStore.Save()
+		c.SessionStore.Update(func(s *session.Session) {
+			s.LastSeenMessageTime = lastSeenMessageTime
+		})
 	}
 
 	// Only keep messages that are more recent than messageTimeoutTime
@@ -301,5 +375,48 @@ func (c *NanitClient) FetchNewMessages(babyUID string, defaultMessageTimeout tim
 	log.Debug().Msgf("Found %d new messages", len(filteredMessages))
 	log.Debug().Msgf("%+v\n", filteredMessages)
 
-	return filteredMessages
+	return filteredMessages, nil
+}
+
+// GetUCToken - returns stored user camera token
+func (c *NanitClient) GetUCToken(cameraUID string) (session.UCToken, bool) {
+	var token session.UCToken
+	var ok bool
+
+	c.SessionStore.View(func(s *session.Session) {
+		token, ok = s.UCTokens[cameraUID]
+	})
+
+	return token, ok
+}
+
+// FetchUCToken - fetches user camera token used for local connection and stores it in the session
+func (c *NanitClient) FetchUCToken(cameraUID string) (string, error) {
+	req, reqErr := http.NewRequest("GET", fmt.Sprintf("https://api.nanit.com/focus/cameras/%v/uc_token", cameraUID), nil)
+
+	if reqErr != nil {
+		log.Fatal().Err(reqErr).Msg("Unable to create request")
+	}
+
+	data := new(ucTokenResponsePayload)
+	if err := c.fetchAuthorized(req, data, "Bearer "); err != nil {
+		return "", fmt.Errorf("Unable to fetch user camera token: %w", err)
+	}
+
+	// Token ends up in the Authorization header of local connections
+	if data.Token == "" || strings.ContainsAny(data.Token, " \t\r\n") {
+		return "", errInvalidUCToken
+	}
+
+	log.Info().Str("camera_uid", cameraUID).Str("token", utils.AnonymizeToken(data.Token, 4)).Msg("Fetched user camera token")
+
+	c.SessionStore.Update(func(s *session.Session) {
+		if s.UCTokens == nil {
+			s.UCTokens = make(map[string]session.UCToken)
+		}
+
+		s.UCTokens[cameraUID] = session.UCToken{Token: data.Token, FetchTime: time.Now()}
+	})
+
+	return data.Token, nil
 }
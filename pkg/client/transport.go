@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// Transport - establishes websocket connections
+type Transport interface {
+	// Dial - connects to the websocket URL, gives up once the context is done
+	Dial(ctx context.Context, url string, header http.Header) (Socket, error)
+}
+
+// Socket - established websocket connection exchanging binary messages
+type Socket interface {
+	// ReadMessage - blocks until next binary message is received, returns error once the connection is closed
+	ReadMessage() ([]byte, error)
+
+	// WriteMessage - sends binary message, safe for concurrent use
+	WriteMessage(data []byte) error
+
+	// Close - closes the connection, pending ReadMessage returns error
+	Close() error
+}
+
+// TransportOpts - options of the default websocket transport
+type TransportOpts struct {
+	// TLS configuration, system defaults are used if nil
+	TLSConfig *tls.Config
+
+	// HTTP proxy selection, http.ProxyFromEnvironment is used if nil
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// Timeout of establishing the connection including TLS and websocket handshake (default 30s)
+	DialTimeout time.Duration
+}
+
+// LocalCamTLSConfig - TLS configuration for cams connected over LAN
+// Cams present a self-signed certificate (with wrong CName) which can't be verified against system CAs.
+// If fingerprint (hex encoded SHA-256 of the certificate, colons are ignored) is given only that certificate is accepted, otherwise any certificate is.
+func LocalCamTLSConfig(fingerprint string) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: true}
+	if fingerprint == "" {
+		return config, nil
+	}
+
+	pinned, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+	if err != nil || len(pinned) != sha256.Size {
+		return nil, errors.New("Invalid certificate fingerprint, expected hex encoded SHA-256")
+	}
+
+	config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("No certificate presented by the cam")
+		}
+
+		if sum := sha256.Sum256(rawCerts[0]); !bytes.Equal(sum[:], pinned) {
+			return errors.New("Certificate presented by the cam does not match the pinned fingerprint " + hex.EncodeToString(sum[:]))
+		}
+
+		return nil
+	}
+
+	return config, nil
+}
+
+// NewTransport - creates default websocket transport
+func NewTransport(opts TransportOpts) Transport {
+	proxy := opts.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
+	dialTimeout := opts.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = 30 * time.Second
+	}
+
+	return &gorillaTransport{
+		dialTimeout: dialTimeout,
+		dialer: &websocket.Dialer{
+			Proxy:            proxy,
+			TLSClientConfig:  opts.TLSConfig,
+			HandshakeTimeout: dialTimeout,
+			NetDial: (&net.Dialer{
+				Timeout: dialTimeout,
+			}).Dial,
+		},
+	}
+}
+
+type gorillaTransport struct {
+	dialTimeout time.Duration
+	dialer      *websocket.Dialer
+}
+
+func (transport *gorillaTransport) Dial(ctx context.Context, url string, header http.Header) (Socket, error) {
+	ctx, cancel := context.WithTimeout(ctx, transport.dialTimeout)
+	defer cancel()
+
+	conn, _, err := transport.dialer.DialContext(ctx, url, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gorillaSocket{conn: conn}, nil
+}
+
+type gorillaSocket struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (socket *gorillaSocket) ReadMessage() ([]byte, error) {
+	for {
+		messageType, data, err := socket.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		if messageType == websocket.BinaryMessage {
+			return data, nil
+		}
+
+		log.Debug().Int("type", messageType).Bytes("data", data).Msg("Ignoring non-binary websocket message")
+	}
+}
+
+func (socket *gorillaSocket) WriteMessage(data []byte) error {
+	socket.writeMu.Lock()
+	defer socket.writeMu.Unlock()
+
+	return socket.conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func (socket *gorillaSocket) Close() error {
+	socket.writeMu.Lock()
+	socket.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	socket.writeMu.Unlock()
+
+	return socket.conn.Close()
+}
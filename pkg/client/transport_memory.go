@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// MemoryTransport - in-memory transport for tests, dialed connections are handed over through Accepted()
+type MemoryTransport struct {
+	acceptedC chan MemoryConn
+}
+
+// MemoryConn - server side of a dialed in-memory connection
+type MemoryConn struct {
+	Socket
+	URL    string
+	Header http.Header
+}
+
+// NewMemoryTransport - constructor
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{
+		acceptedC: make(chan MemoryConn),
+	}
+}
+
+// Accepted - returns channel of server sides of dialed connections
+func (transport *MemoryTransport) Accepted() <-chan MemoryConn {
+	return transport.acceptedC
+}
+
+// Dial - blocks until the connection is accepted by reading from Accepted()
+func (transport *MemoryTransport) Dial(ctx context.Context, url string, header http.Header) (Socket, error) {
+	client, server := NewMemorySocketPair()
+
+	select {
+	case transport.acceptedC <- MemoryConn{Socket: server, URL: url, Header: header}:
+		return client, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// NewMemorySocketPair - returns two sockets connected to each other
+func NewMemorySocketPair() (Socket, Socket) {
+	aToB := make(chan []byte, 64)
+	bToA := make(chan []byte, 64)
+	closed := &memorySocketClosed{c: make(chan struct{})}
+
+	return &memorySocket{in: bToA, out: aToB, closed: closed},
+		&memorySocket{in: aToB, out: bToA, closed: closed}
+}
+
+type memorySocketClosed struct {
+	once sync.Once
+	c    chan struct{}
+}
+
+type memorySocket struct {
+	in     <-chan []byte
+	out    chan<- []byte
+	closed *memorySocketClosed
+}
+
+func (socket *memorySocket) ReadMessage() ([]byte, error) {
+	select {
+	case data := <-socket.in:
+		return data, nil
+	case <-socket.closed.c:
+		return nil, io.EOF
+	}
+}
+
+func (socket *memorySocket) WriteMessage(data []byte) error {
+	select {
+	case <-socket.closed.c:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	select {
+	case socket.out <- data:
+		return nil
+	case <-socket.closed.c:
+		return io.ErrClosedPipe
+	}
+}
+
+// Close - closes both sides of the pair
+func (socket *memorySocket) Close() error {
+	socket.closed.once.Do(func() {
+		close(socket.closed.c)
+	})
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCamTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	get := func(fingerprint string) error {
+		config, err := LocalCamTLSConfig(fingerprint)
+		require.NoError(t, err)
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+		res, err := client.Get(server.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+
+		return err
+	}
+
+	sum := sha256.Sum256(server.Certificate().Raw)
+
+	assert.NoError(t, get(""), "Self-signed certificate is accepted by default")
+	assert.NoError(t, get(hex.EncodeToString(sum[:])))
+	assert.Error(t, get(hex.EncodeToString(make([]byte, sha256.Size))))
+
+	_, err := LocalCamTLSConfig("AB:CD")
+	assert.Error(t, err)
+}
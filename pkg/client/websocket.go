@@ -1,17 +1,19 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	sync "sync"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
-	"github.com/sacOO7/gowebsocket"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/gregory-m/nanit/pkg/baby"
-	"github.com/gregory-m/nanit/pkg/session"
 	"github.com/gregory-m/nanit/pkg/utils"
 )
 
@@ -23,31 +25,58 @@ type readyState struct {
 // WebsocketConnectionHandler - handler of ready connection
 type WebsocketConnectionHandler func(*WebsocketConnection, utils.GracefulContext)
 
+// WebsocketConnectionSetup - prepares new connection before any message is received (ie. registers handlers)
+type WebsocketConnectionSetup func(*WebsocketConnection)
+
 // WebsocketConnectionManager - connection manager
 type WebsocketConnectionManager struct {
 	BabyUID          string
 	CameraUID        string
-	Session          *session.Session
 	API              *NanitClient
 	BabyStateManager *baby.StateManager
 
+	// IP:Port of the cam for direct connection over LAN (optional)
+	LocalAddr string
+
+	// Recorder of the traffic of all connections (optional)
+	Recorder *Recorder
+
+	// Inspector of messages received by all connections (optional)
+	Inspector *Inspector
+
+	// Transport used for connecting, LocalTransport is used for local connections if set
+	Transport      Transport
+	LocalTransport Transport
+
+	// Interval of keepalive messages
+	KeepaliveInterval time.Duration
+
+	// Connection is considered dead and reconnected when nothing is received for this long (0 = never)
+	KeepaliveTimeout time.Duration
+
 	mu               sync.RWMutex
 	readyState       *readyState
 	readySubscribers []WebsocketConnectionHandler
+	setupHandlers    []WebsocketConnectionSetup
 }
 
+var errConnectionDead = errors.New("Connection is dead")
+
 // NewWebsocketConnectionManager - constructor
-func NewWebsocketConnectionManager(babyUID string, cameraUID string, session *session.Session, api *NanitClient, babyStateManager *baby.StateManager) *WebsocketConnectionManager {
+func NewWebsocketConnectionManager(babyUID string, cameraUID string, api *NanitClient, babyStateManager *baby.StateManager) *WebsocketConnectionManager {
 	manager := &WebsocketConnectionManager{
 		BabyUID:          babyUID,
 		CameraUID:        cameraUID,
-		Session:          session,
 		API:              api,
 		BabyStateManager: babyStateManager,
+		Transport:        NewTransport(TransportOpts{}),
+
+		KeepaliveInterval: 20 * time.Second,
+		KeepaliveTimeout:  DefaultKeepaliveTimeout,
 	}
 
 	manager.WithReadyConnection(func(conn *WebsocketConnection, ctx utils.GracefulContext) {
-		ticker := time.NewTicker(20 * time.Second)
+		ticker := time.NewTicker(manager.KeepaliveInterval)
 
 		for {
 			select {
@@ -55,9 +84,21 @@ func NewWebsocketConnectionManager(babyUID string, cameraUID string, session *se
 				ticker.Stop()
 				return
 			case <-ticker.C:
-				conn.SendMessage(&Message{
-					Type: Message_Type(Message_KEEPALIVE).Enum(),
-				})
+				conn.SendKeepalive()
+
+				stateUpdate := baby.NewState().SetLastSeenTimestamp(int32(conn.LastSeen().Unix()))
+				if latency := conn.KeepaliveLatency(); latency > 0 {
+					stateUpdate.SetKeepaliveLatencyMilli(int32(latency.Milliseconds()))
+				}
+
+				stats := conn.DispatchStats()
+				stateUpdate.SetDispatchQueued(int32(stats.Queued)).
+					SetDispatchDropped(int32(stats.Dropped)).
+					SetDispatchOverflowed(int32(stats.Overflowed)).
+					SetDispatchLatencyMilli(int32(stats.AvgLatency().Milliseconds())).
+					SetDispatchMaxLatencyMilli(int32(stats.MaxLatency.Milliseconds()))
+
+				manager.BabyStateManager.Update(manager.BabyUID, *stateUpdate)
 			}
 		}
 	})
@@ -78,6 +119,26 @@ func (manager *WebsocketConnectionManager) WithReadyConnection(handler Websocket
 	}
 }
 
+// WithConnectionSetup - registers handler which will be called synchronously for every new connection before reading of messages starts
+// Applies to connections established after the registration only
+func (manager *WebsocketConnectionManager) WithConnectionSetup(handler WebsocketConnectionSetup) {
+	manager.mu.Lock()
+	manager.setupHandlers = append(manager.setupHandlers, handler)
+	manager.mu.Unlock()
+}
+
+// GetReadyConnection - returns currently ready connection or nil if there is none
+func (manager *WebsocketConnectionManager) GetReadyConnection() *WebsocketConnection {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	if manager.readyState == nil {
+		return nil
+	}
+
+	return manager.readyState.Connection
+}
+
 // RunWithinContext - starts websocket connection attempt loop
 func (manager *WebsocketConnectionManager) RunWithinContext(ctx utils.GracefulContext) {
 	utils.RunWithPerseverance(manager.run, ctx, utils.PerseverenceOpts{
@@ -94,58 +155,154 @@ func (manager *WebsocketConnectionManager) RunWithinContext(ctx utils.GracefulCo
 }
 
 func (manager *WebsocketConnectionManager) run(attempt utils.AttemptContext) {
+	// Local
+	if manager.LocalAddr != "" {
+		if token := manager.getUCToken(); token != "" {
+			url := fmt.Sprintf("wss://%v", manager.LocalAddr)
+			auth := fmt.Sprintf("token %v", token)
+
+			err := manager.connect(attempt, url, auth, true)
+			if err == nil {
+				return
+			}
+
+			log.Warn().Str("url", url).Err(err).Msg("Unable to connect to the cam locally, falling back to remote connection")
+		}
+	}
+
 	// Reauthorize if it is not a first try or we assume we don't have a valid token
-	manager.API.MaybeAuthorize(attempt.GetTry() > 1)
+	if err := manager.API.MaybeAuthorize(attempt.GetTry() > 1); err != nil {
+		attempt.Fail(err)
+		return
+	}
 
 	// Remote
 	url := fmt.Sprintf("wss://api.nanit.com/focus/cameras/%v/user_connect", manager.CameraUID)
-	auth := fmt.Sprintf("Bearer %v", manager.Session.AuthToken)
+	auth := fmt.Sprintf("Bearer %v", manager.API.GetAuthToken())
 
-	// Local
-	// url := "wss://192.168.3.195:442"
-	// auth := fmt.Sprintf("token %v", userCamToken)
+	err := manager.connect(attempt, url, auth, false)
+	if err != nil {
+		attempt.Fail(err)
+	}
+}
+
+// getUCToken - returns stored user camera token for local connection, empty if we have not received any yet
+func (manager *WebsocketConnectionManager) getUCToken() string {
+	token, _ := manager.API.GetUCToken(manager.CameraUID)
+	return token.Token
+}
+
+// refreshUCToken - fetches user camera token while we are online, so that we have a fresh one in case we go offline
+func (manager *WebsocketConnectionManager) refreshUCToken(force bool) {
+	if token, ok := manager.API.GetUCToken(manager.CameraUID); ok && !force && time.Since(token.FetchTime) < UCTokenTimelife {
+		return
+	}
+
+	if _, err := manager.API.FetchUCToken(manager.CameraUID); err != nil {
+		log.Warn().Err(err).Str("camera_uid", manager.CameraUID).Msg("Unable to fetch user camera token")
+	}
+}
 
-	// -------
+// handleUCTokens - refetches user camera token when the cloud announces a change
+func (manager *WebsocketConnectionManager) handleUCTokens(_ *Request, _ *WebsocketConnection) *Response {
+	if manager.LocalAddr != "" {
+		log.Debug().Str("camera_uid", manager.CameraUID).Msg("User camera tokens changed, refetching")
+		go manager.refreshUCToken(true)
+	}
 
-	var once sync.Once // Just because gowebsocket is buggy and can invoke OnDisconnect multiple times :-/
+	return nil
+}
 
-	socket := gowebsocket.New(url)
-	socket.RequestHeader.Set("Authorization", auth)
+// connect - connects to the websocket and blocks until the attempt is done
+// Returns error only if the connection could not be established
+func (manager *WebsocketConnectionManager) connect(attempt utils.AttemptContext, url string, auth string, isLocal bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-attempt.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
-	// Handle new connection
-	socket.OnConnected = func(socket gowebsocket.Socket) {
-		log.Info().Str("url", url).Msg("Connected to websocket")
+	header := http.Header{}
+	header.Set("Authorization", auth)
 
-		go func() {
-			conn := NewWebsocketConnection(&socket)
-			readyState := readyState{attempt, conn}
+	log.Trace().Str("url", url).Msg("Connecting to websocket")
 
-			manager.mu.Lock()
-			manager.readyState = &readyState
-			subscribedHandlers := make([]WebsocketConnectionHandler, len(manager.readySubscribers))
-			copy(subscribedHandlers, manager.readySubscribers)
-			manager.mu.Unlock()
+	socket, err := manager.getTransport(isLocal).Dial(ctx, url, header)
+	if err != nil {
+		log.Error().Str("url", url).Err(err).Msg("Unable to establish websocket connection")
+		return err
+	}
 
-			manager.BabyStateManager.Update(manager.BabyUID, *baby.NewState().SetWebsocketAlive(true))
+	log.Info().Str("url", url).Msg("Connected to websocket")
 
-			log.Trace().Int("num_handlers", len(subscribedHandlers)).Msg("Notifying websocket ready handlers")
+	conn := NewWebsocketConnection(socket)
+	conn.Recorder = manager.Recorder
+	conn.HandleRequest(RequestType_PUT_UCTOKENS, manager.handleUCTokens)
+	conn.Inspector = manager.Inspector
 
-			for _, handler := range subscribedHandlers {
-				notifyReadyHandler(handler, readyState)
-			}
-		}()
+	manager.mu.RLock()
+	setupHandlers := make([]WebsocketConnectionSetup, len(manager.setupHandlers))
+	copy(setupHandlers, manager.setupHandlers)
+	manager.mu.RUnlock()
+
+	for _, setup := range setupHandlers {
+		setup(conn)
 	}
 
-	// Handle failed attempts for connection
-	socket.OnConnectError = func(err error, socket gowebsocket.Socket) {
-		log.Error().Str("url", url).Err(err).Msg("Unable to establish websocket connection")
-		attempt.Fail(err)
+	readyState := readyState{attempt, conn}
+
+	manager.mu.Lock()
+	manager.readyState = &readyState
+	subscribedHandlers := make([]WebsocketConnectionHandler, len(manager.readySubscribers))
+	copy(subscribedHandlers, manager.readySubscribers)
+	manager.mu.Unlock()
+
+	manager.BabyStateManager.Update(manager.BabyUID, *baby.NewState().SetWebsocketAlive(true).SetIsLocalConnection(isLocal))
+
+	// Token for local connection can be only obtained through the cloud
+	if !isLocal && manager.LocalAddr != "" {
+		go manager.refreshUCToken(false)
+	}
+
+	// Reading messages
+	readErrC := make(chan error, 1)
+	go func() {
+		readErrC <- manager.readMessages(socket, conn)
+	}()
+
+	go func() {
+		log.Trace().Int("num_handlers", len(subscribedHandlers)).Msg("Notifying websocket ready handlers")
+
+		for _, handler := range subscribedHandlers {
+			notifyReadyHandler(handler, readyState)
+		}
+	}()
+
+	// Dead connection detection
+	var watchdogC <-chan time.Time
+	if manager.KeepaliveTimeout > 0 {
+		watchdog := time.NewTicker(manager.KeepaliveTimeout / 4)
+		defer watchdog.Stop()
+		watchdogC = watchdog.C
 	}
 
-	// Handle lost connection
-	socket.OnDisconnected = func(err error, socket gowebsocket.Socket) {
-		once.Do(func() {
-			manager.BabyStateManager.Update(manager.BabyUID, *baby.NewState().SetWebsocketAlive(false))
+	for {
+		select {
+		case <-attempt.Done():
+			log.Debug().Msg("Closing websocket")
+			socket.Close()
+			<-readErrC
+			manager.disconnect(conn)
+			return nil
+
+		case err := <-readErrC:
+			socket.Close()
+			manager.disconnect(conn)
 
 			if err != nil {
 				log.Error().Err(err).Msg("Disconnected from server")
@@ -154,35 +311,77 @@ func (manager *WebsocketConnectionManager) run(attempt utils.AttemptContext) {
 				log.Warn().Msg("Disconnected from server")
 				attempt.Fail(errors.New("Server closed the connection"))
 			}
-		})
+
+			return nil
+
+		case <-watchdogC:
+			silence := time.Since(conn.LastSeen())
+			if silence < manager.KeepaliveTimeout {
+				continue
+			}
+
+			log.Error().Dur("silence", silence).Msg("Nothing received from the cam for too long, considering connection dead")
+			socket.Close()
+			<-readErrC
+			manager.disconnect(conn)
+			attempt.Fail(errConnectionDead)
+			return nil
+		}
 	}
+}
+
+// readMessages - reads messages from the socket and dispatches them to the connection until the socket is closed
+func (manager *WebsocketConnectionManager) readMessages(socket Socket, conn *WebsocketConnection) error {
+	for {
+		data, err := socket.ReadMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) || websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil
+			}
+
+			return err
+		}
 
-	socket.OnBinaryMessage = func(data []byte, _ gowebsocket.Socket) {
 		m := &Message{}
-		err := proto.Unmarshal(data, m)
+		err = proto.Unmarshal(data, m)
 		if err != nil {
 			log.Error().Err(err).Bytes("rawdata", data).Msg("Received malformed binary message")
-			return
+			continue
 		}
 
 		log.Debug().Stringer("data", m).Msg("Received message")
+		conn.markSeen(m)
+
+		if manager.Recorder != nil {
+			manager.Recorder.Record(RecordReceived, m, data)
+		}
 
-		manager.mu.RLock()
-		readyState := manager.readyState
-		manager.mu.RUnlock()
+		conn.Dispatch(m)
+	}
+}
 
-		go readyState.Connection.handleMessage(m)
+// disconnect - forgets the connection and fails requests still waiting for the response
+func (manager *WebsocketConnectionManager) disconnect(conn *WebsocketConnection) {
+	manager.mu.Lock()
+	if manager.readyState != nil && manager.readyState.Connection == conn {
+		manager.readyState = nil
 	}
+	manager.mu.Unlock()
 
-	log.Trace().Msg("Connecting to websocket")
-	socket.Connect()
+	conn.Close()
 
-	<-attempt.Done()
+	stats := conn.DispatchStats()
+	log.Debug().Uint64("dispatched", stats.Dispatched).Uint64("dropped", stats.Dropped).Uint64("overflowed", stats.Overflowed).Dur("avg_latency", stats.AvgLatency()).Dur("max_latency", stats.MaxLatency).Msg("Message dispatch stats")
 
-	if socket.IsConnected {
-		log.Debug().Msg("Closing websocket")
-		socket.Close()
+	manager.BabyStateManager.Update(manager.BabyUID, *baby.NewState().SetWebsocketAlive(false))
+}
+
+func (manager *WebsocketConnectionManager) getTransport(isLocal bool) Transport {
+	if isLocal && manager.LocalTransport != nil {
+		return manager.LocalTransport
 	}
+
+	return manager.Transport
 }
 
 func notifyReadyHandler(handler WebsocketConnectionHandler, state readyState) {
@@ -1,15 +1,14 @@
 package client
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"github.com/sacOO7/gowebsocket"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/gregory-m/nanit/pkg/utils"
@@ -20,24 +19,53 @@ type WebsocketMessageHandler func(*Message, *WebsocketConnection)
 
 // WebsocketConnection - ready websocket connection
 type WebsocketConnection struct {
-	socket *gowebsocket.Socket
+	socket Socket
 
 	msgHandlersMu sync.RWMutex
 	msgHandlers   []WebsocketMessageHandler
+	dispatcher    *dispatcher
 
-	resHandlersMu sync.RWMutex
-	resHandlers   map[int32]unhandledRequest
+	reqHandlersMu     sync.Mutex
+	reqHandlers       map[RequestType]WebsocketRequestHandler
+	unhandledReqTypes map[RequestType]bool
+
+	resHandlersMu sync.Mutex
+	resHandlers   map[int32]pendingRequest
+	closed        bool
+	closedC       chan struct{}
 
 	lastRequestID int32
+
+	// Liveness tracking, unix nano timestamps / duration accessed atomically
+	lastSeen         int64
+	keepaliveSentAt  int64
+	keepaliveLatency int64
+
+	// Recorder of the traffic (optional)
+	Recorder *Recorder
+
+	// Inspector of received messages reporting parts not covered by the proto file (optional)
+	Inspector *Inspector
 }
 
 // NewWebsocketConnection - constructor
-func NewWebsocketConnection(socket *gowebsocket.Socket) *WebsocketConnection {
-	return &WebsocketConnection{
+// Socket can be nil for offline connections (ie. replay), messages are then not sent anywhere
+func NewWebsocketConnection(socket Socket) *WebsocketConnection {
+	conn := &WebsocketConnection{
 		socket:        socket,
-		resHandlers:   make(map[int32]unhandledRequest),
+		dispatcher:    newDispatcher(),
+		resHandlers:   make(map[int32]pendingRequest),
+		closedC:       make(chan struct{}),
 		lastRequestID: 0,
+		lastSeen:      time.Now().UnixNano(),
+
+		reqHandlers:       make(map[RequestType]WebsocketRequestHandler),
+		unhandledReqTypes: make(map[RequestType]bool),
 	}
+
+	go conn.runDispatcher()
+
+	return conn
 }
 
 // RegisterMessageHandler - registers handler which will be called whenever new message is received
@@ -48,7 +76,7 @@ func (conn *WebsocketConnection) RegisterMessageHandler(handler WebsocketMessage
 }
 
 // SendMessage - low-level helper for sending raw message
-// Note: Use SendRequest() for requests
+// Note: Use Do() or Send() for requests
 func (conn *WebsocketConnection) SendMessage(m *Message) {
 	var msg *zerolog.Event
 
@@ -63,96 +91,175 @@ func (conn *WebsocketConnection) SendMessage(m *Message) {
 	bytes := getMessageBytes(m)
 	log.Trace().Bytes("rawdata", bytes).Msg("Sending data")
 
-	conn.socket.SendBinary(bytes)
+	if conn.Recorder != nil {
+		conn.Recorder.Record(RecordSent, m, bytes)
+	}
+
+	if conn.socket == nil {
+		log.Trace().Msg("Offline connection, message not sent")
+		return
+	}
+
+	if err := conn.socket.WriteMessage(bytes); err != nil {
+		log.Warn().Err(err).Msg("Unable to send message")
+	}
 }
 
-// SendRequest - sends request to the cam and returns await function. Await function waits for the response and returns it
-func (conn *WebsocketConnection) SendRequest(reqType RequestType, requestData *Request) func(time.Duration) (*Response, error) {
-	// Build request
-	id := atomic.AddInt32(&conn.lastRequestID, 1)
+// SendKeepalive - sends keepalive message, round-trip is measured once the cam sends keepalive back
+func (conn *WebsocketConnection) SendKeepalive() {
+	atomic.CompareAndSwapInt64(&conn.keepaliveSentAt, 0, time.Now().UnixNano())
 
-	requestData.Id = utils.ConstRefInt32(id)
-	requestData.Type = RequestType(reqType).Enum()
+	conn.SendMessage(&Message{
+		Type: Message_Type(Message_KEEPALIVE).Enum(),
+	})
+}
 
-	m := &Message{
-		Type:    Message_Type(Message_REQUEST).Enum(),
-		Request: requestData,
+// LastSeen - returns time of the last message received from the cam (or of the connection establishment)
+func (conn *WebsocketConnection) LastSeen() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&conn.lastSeen))
+}
+
+// KeepaliveLatency - returns last measured keepalive round-trip, 0 if not measured yet
+func (conn *WebsocketConnection) KeepaliveLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&conn.keepaliveLatency))
+}
+
+// markSeen - records that the message was just received
+func (conn *WebsocketConnection) markSeen(m *Message) {
+	now := time.Now().UnixNano()
+	atomic.StoreInt64(&conn.lastSeen, now)
+
+	if m.GetType() == Message_KEEPALIVE {
+		if sentAt := atomic.SwapInt64(&conn.keepaliveSentAt, 0); sentAt != 0 {
+			atomic.StoreInt64(&conn.keepaliveLatency, now-sentAt)
+		}
 	}
+}
 
-	// Response handling
+// Send - sends request to the cam without waiting for the response
+// Response is still passed to the registered message handlers
+func (conn *WebsocketConnection) Send(reqType RequestType, requestData *Request) {
+	conn.SendMessage(conn.newRequestMessage(reqType, requestData))
+}
+
+// Do - sends request to the cam and waits for the response until the context is done
+// Returns ErrTimeout when the context deadline is exceeded, ErrConnectionClosed when the connection closes in the meantime
+// and *ErrStatus when the cam responds with non-200 status (along with the response)
+func (conn *WebsocketConnection) Do(ctx context.Context, reqType RequestType, requestData *Request) (*Response, error) {
+	m := conn.newRequestMessage(reqType, requestData)
+	id := m.Request.GetId()
+
+	// Buffered, so that the response is never blocking the dispatch even if nobody is waiting anymore
 	resC := make(chan *Response, 1)
 
 	conn.resHandlersMu.Lock()
-	conn.resHandlers[id] = unhandledRequest{
-		Request: m.Request,
-		HandleResponse: func(res *Response) {
-			select {
-			case <-resC:
-				return // Channel already closed (ie. timeout)
-			default:
-				resC <- res
-			}
-		},
+	if conn.closed {
+		conn.resHandlersMu.Unlock()
+		return nil, ErrConnectionClosed
+	}
+
+	conn.resHandlers[id] = pendingRequest{
+		Type:      reqType,
+		ResponseC: resC,
 	}
 	conn.resHandlersMu.Unlock()
 
-	// Send request
+	// Request is no longer pending once we return, whatever the outcome
+	defer conn.removePendingRequest(id)
+
 	conn.SendMessage(m)
 
-	// Return awaiter
-	return func(timeout time.Duration) (*Response, error) {
-		timer := time.NewTimer(timeout)
-
-		select {
-		case <-timer.C:
-			close(resC)
-			return nil, errors.New("Request timeout")
-		case res := <-resC:
-			close(resC)
-			timer.Stop()
-
-			if res.StatusCode == nil {
-				return res, errors.New("No status code received")
-			} else if *res.StatusCode != 200 {
-				if res.GetStatusMessage() != "" {
-					return res, errors.New(res.GetStatusMessage())
-				}
-
-				return res, fmt.Errorf("Unexpected status code %v", *res.StatusCode)
-			}
-
-			return res, nil
+	select {
+	case res := <-resC:
+		return res, getResponseError(res)
+	case <-conn.closedC:
+		return nil, ErrConnectionClosed
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, ErrTimeout
 		}
+
+		return nil, ctx.Err()
 	}
 }
 
-type unhandledRequest struct {
-	Request        *Request
-	HandleResponse func(response *Response)
+// Close - fails all pending requests and stops message dispatch, further requests fail immediately
+// Note: It does not close the underlying socket, it is expected to be closed already
+func (conn *WebsocketConnection) Close() {
+	conn.resHandlersMu.Lock()
+	defer conn.resHandlersMu.Unlock()
+
+	if !conn.closed {
+		conn.closed = true
+		conn.resHandlers = make(map[int32]pendingRequest)
+		close(conn.closedC)
+	}
 }
 
-func (conn *WebsocketConnection) handleResponse(r *Response) {
-	requestID := *r.RequestId
-	requestType := *r.RequestType
+func (conn *WebsocketConnection) newRequestMessage(reqType RequestType, requestData *Request) *Message {
+	id := atomic.AddInt32(&conn.lastRequestID, 1)
 
-	conn.resHandlersMu.RLock()
-	unhandledReqCandidate, ok := conn.resHandlers[requestID]
-	conn.resHandlersMu.RUnlock()
+	requestData.Id = utils.ConstRefInt32(id)
+	requestData.Type = RequestType(reqType).Enum()
 
-	if ok && requestType == *unhandledReqCandidate.Request.Type {
-		conn.resHandlersMu.Lock()
-		delete(conn.resHandlers, requestID)
-		conn.resHandlersMu.Unlock()
+	return &Message{
+		Type:    Message_Type(Message_REQUEST).Enum(),
+		Request: requestData,
+	}
+}
+
+func (conn *WebsocketConnection) removePendingRequest(id int32) {
+	conn.resHandlersMu.Lock()
+	delete(conn.resHandlers, id)
+	conn.resHandlersMu.Unlock()
+}
 
-		unhandledReqCandidate.HandleResponse(r)
+func getResponseError(res *Response) error {
+	if res.StatusCode == nil {
+		return errors.New("No status code received")
+	} else if *res.StatusCode != 200 {
+		return &ErrStatus{Code: res.GetStatusCode(), Message: res.GetStatusMessage()}
 	}
+
+	return nil
 }
 
+type pendingRequest struct {
+	Type      RequestType
+	ResponseC chan<- *Response
+}
+
+func (conn *WebsocketConnection) handleResponse(r *Response) {
+	conn.resHandlersMu.Lock()
+	pending, ok := conn.resHandlers[r.GetRequestId()]
+	if ok && r.GetRequestType() == pending.Type {
+		delete(conn.resHandlers, r.GetRequestId())
+	}
+	conn.resHandlersMu.Unlock()
+
+	if ok && r.GetRequestType() == pending.Type {
+		pending.ResponseC <- r
+	}
+}
+
+// handleMessage - synchronously processes received message (see Dispatch for asynchronous variant)
 func (conn *WebsocketConnection) handleMessage(m *Message) {
+	if conn.Inspector != nil {
+		conn.Inspector.Inspect(m)
+	}
+
+	if *m.Type == Message_REQUEST && m.Request != nil {
+		conn.handleRequest(m.Request)
+	}
+
+	conn.notifyMessageHandlers(m)
+
 	if *m.Type == Message_RESPONSE && m.Response != nil {
 		conn.handleResponse(m.Response)
 	}
+}
 
+func (conn *WebsocketConnection) notifyMessageHandlers(m *Message) {
 	conn.msgHandlersMu.RLock()
 	subscribedHandlers := make([]WebsocketMessageHandler, len(conn.msgHandlers))
 	copy(subscribedHandlers, conn.msgHandlers)
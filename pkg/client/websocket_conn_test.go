@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+func newTestResponse(requestID int32, statusCode int32, statusMessage string) *Message {
+	res := &Response{
+		RequestId:   utils.ConstRefInt32(requestID),
+		RequestType: RequestType_GET_STATUS.Enum(),
+		StatusCode:  utils.ConstRefInt32(statusCode),
+	}
+
+	if statusMessage != "" {
+		res.StatusMessage = utils.ConstRefStr(statusMessage)
+	}
+
+	return &Message{Type: Message_RESPONSE.Enum(), Response: res}
+}
+
+// respondWhenPending - handles the response as soon as the request is waiting for it
+func respondWhenPending(conn *WebsocketConnection, m *Message) {
+	for {
+		conn.resHandlersMu.Lock()
+		_, pending := conn.resHandlers[m.Response.GetRequestId()]
+		conn.resHandlersMu.Unlock()
+
+		if pending {
+			conn.handleMessage(m)
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDoResponse(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+
+	go respondWhenPending(conn, newTestResponse(1, 200, ""))
+
+	res, err := conn.Do(context.Background(), RequestType_GET_STATUS, &Request{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), res.GetRequestId())
+	assert.Empty(t, conn.resHandlers)
+}
+
+func TestDoErrStatus(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+
+	go respondWhenPending(conn, newTestResponse(1, 403, appConnectionLimitMessage))
+	_, err := conn.Do(context.Background(), RequestType_GET_STATUS, &Request{})
+
+	var errStatus *ErrStatus
+	assert.True(t, errors.As(err, &errStatus))
+	assert.Equal(t, int32(403), errStatus.Code)
+	assert.True(t, errors.Is(err, ErrAppConnectionLimit))
+
+	go respondWhenPending(conn, newTestResponse(2, 500, ""))
+	_, err = conn.Do(context.Background(), RequestType_GET_STATUS, &Request{})
+
+	assert.EqualError(t, err, "Unexpected status code 500")
+	assert.False(t, errors.Is(err, ErrAppConnectionLimit))
+}
+
+func TestDoTimeout(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := conn.Do(ctx, RequestType_GET_STATUS, &Request{})
+	assert.Equal(t, ErrTimeout, err)
+	assert.Empty(t, conn.resHandlers)
+
+	// Late response must not block
+	conn.handleMessage(newTestResponse(1, 200, ""))
+}
+
+func TestDoConnectionClosed(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		conn.Close()
+	}()
+
+	_, err := conn.Do(context.Background(), RequestType_GET_STATUS, &Request{})
+	assert.Equal(t, ErrConnectionClosed, err)
+
+	_, err = conn.Do(context.Background(), RequestType_GET_STATUS, &Request{})
+	assert.Equal(t, ErrConnectionClosed, err)
+	assert.Empty(t, conn.resHandlers)
+}
+
+func TestGetStatusNetwork(t *testing.T) {
+	conn := NewWebsocketConnection(nil)
+
+	unknown := protowire.AppendTag(nil, 20, protowire.BytesType)
+	unknown = protowire.AppendString(unknown, "home")
+
+	m := newTestResponse(1, 200, "")
+	m.Response.RequestType = RequestType_GET_STATUS_NETWORK.Enum()
+	m.Response.ProtoReflect().SetUnknown(unknown)
+
+	go respondWhenPending(conn, m)
+
+	status, err := conn.GetStatusNetwork(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, RawNetworkStatus{{Number: 20, Type: "string", Value: "home"}}, status)
+}
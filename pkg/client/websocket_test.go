@@ -0,0 +1,183 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gregory-m/nanit/pkg/baby"
+	"github.com/gregory-m/nanit/pkg/client"
+	"github.com/gregory-m/nanit/pkg/session"
+	"github.com/gregory-m/nanit/pkg/utils"
+)
+
+func newTestManager(transport client.Transport) (*client.WebsocketConnectionManager, *baby.StateManager) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.AuthToken = "token"
+	sessionStore.Session.AuthTime = time.Now()
+
+	stateManager := baby.NewStateManager()
+	manager := client.NewWebsocketConnectionManager("baby", "camera", &client.NanitClient{SessionStore: sessionStore}, stateManager)
+	manager.Transport = transport
+
+	return manager, stateManager
+}
+
+func TestManagerWithMemoryTransport(t *testing.T) {
+	transport := client.NewMemoryTransport()
+	manager, stateManager := newTestManager(transport)
+
+	connC := make(chan *client.WebsocketConnection, 1)
+	manager.WithReadyConnection(func(conn *client.WebsocketConnection, _ utils.GracefulContext) {
+		connC <- conn
+	})
+
+	runner := utils.RunWithGracefulCancel(manager.RunWithinContext)
+	defer runner.Cancel()
+
+	server := <-transport.Accepted()
+	assert.Equal(t, "wss://api.nanit.com/focus/cameras/camera/user_connect", server.URL)
+	assert.Equal(t, "Bearer token", server.Header.Get("Authorization"))
+
+	conn := <-connC
+	assert.Equal(t, utils.ConstRefBool(true), stateManager.GetBabyState("baby").IsWebsocketAlive)
+
+	// Cam answers the request
+	go func() {
+		data, err := server.ReadMessage()
+		require.NoError(t, err)
+
+		req := &client.Message{}
+		require.NoError(t, proto.Unmarshal(data, req))
+
+		res, _ := proto.Marshal(&client.Message{
+			Type: client.Message_RESPONSE.Enum(),
+			Response: &client.Response{
+				RequestId:   req.Request.Id,
+				RequestType: req.Request.Type,
+				StatusCode:  utils.ConstRefInt32(200),
+				Status:      &client.Status{CurrentVersion: utils.ConstRefStr("1.2.3")},
+			},
+		})
+
+		server.WriteMessage(res)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	status, err := conn.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", status.GetCurrentVersion())
+
+	// Cam drops the connection
+	server.Close()
+
+	assert.Eventually(t, func() bool {
+		state := stateManager.GetBabyState("baby")
+		return state.IsWebsocketAlive != nil && !*state.IsWebsocketAlive && manager.GetReadyConnection() == nil
+	}, time.Second, time.Millisecond)
+
+	_, err = conn.GetStatus(ctx)
+	assert.Equal(t, client.ErrConnectionClosed, err)
+}
+
+func TestManagerKeepalive(t *testing.T) {
+	transport := client.NewMemoryTransport()
+	manager, _ := newTestManager(transport)
+	manager.KeepaliveInterval = 10 * time.Millisecond
+	manager.KeepaliveTimeout = 100 * time.Millisecond
+
+	connC := make(chan *client.WebsocketConnection, 1)
+	manager.WithReadyConnection(func(conn *client.WebsocketConnection, _ utils.GracefulContext) {
+		connC <- conn
+	})
+
+	runner := utils.RunWithGracefulCancel(manager.RunWithinContext)
+	defer runner.Cancel()
+
+	server := <-transport.Accepted()
+	conn := <-connC
+
+	// Cam answers the first keepalive, then goes silent
+	data, err := server.ReadMessage()
+	require.NoError(t, err)
+
+	m := &client.Message{}
+	require.NoError(t, proto.Unmarshal(data, m))
+	assert.Equal(t, client.Message_KEEPALIVE, m.GetType())
+
+	server.WriteMessage(data)
+
+	assert.Eventually(t, func() bool {
+		return conn.KeepaliveLatency() > 0
+	}, time.Second, time.Millisecond)
+
+	go func() {
+		for {
+			if _, err := server.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	assert.Eventually(t, func() bool {
+		return manager.GetReadyConnection() == nil
+	}, time.Second, time.Millisecond)
+
+	assert.True(t, time.Since(conn.LastSeen()) >= 100*time.Millisecond)
+}
+
+func TestManagerConnectionSetup(t *testing.T) {
+	transport := client.NewMemoryTransport()
+	manager, _ := newTestManager(transport)
+	manager.KeepaliveInterval = time.Hour
+
+	handledC := make(chan int32, 1)
+	manager.WithConnectionSetup(func(conn *client.WebsocketConnection) {
+		conn.HandleRequest(client.RequestType_PUT_SENSOR_DATA, func(r *client.Request, _ *client.WebsocketConnection) *client.Response {
+			handledC <- r.GetId()
+			return nil
+		})
+	})
+
+	runner := utils.RunWithGracefulCancel(manager.RunWithinContext)
+	defer runner.Cancel()
+
+	// Cam pushes data right after connecting
+	server := <-transport.Accepted()
+	req, _ := proto.Marshal(&client.Message{
+		Type: client.Message_REQUEST.Enum(),
+		Request: &client.Request{
+			Id:   utils.ConstRefInt32(1),
+			Type: client.RequestType_PUT_SENSOR_DATA.Enum(),
+		},
+	})
+
+	go server.WriteMessage(req)
+
+	select {
+	case id := <-handledC:
+		assert.Equal(t, int32(1), id)
+	case <-time.After(time.Second):
+		t.Fatal("Request was not handled")
+	}
+
+	for {
+		data, err := server.ReadMessage()
+		require.NoError(t, err)
+
+		m := &client.Message{}
+		require.NoError(t, proto.Unmarshal(data, m))
+
+		if m.GetType() == client.Message_RESPONSE {
+			assert.Equal(t, int32(1), m.Response.GetRequestId())
+			assert.Equal(t, int32(200), m.Response.GetStatusCode())
+			break
+		}
+	}
+}
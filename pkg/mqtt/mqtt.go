@@ -1,7 +1,10 @@
 package mqtt
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	MQTT "github.com/eclipse/paho.mqtt.golang"
@@ -11,19 +14,33 @@ import (
 	"github.com/gregory-m/nanit/pkg/utils"
 )
 
+// CommandHandler - handler of a command received on {prefix}/babies/{baby_uid}/{command}/set topic
+type CommandHandler func(babyUID string, payload []byte)
+
 // Connection - MQTT context
 type Connection struct {
 	Opts         Opts
 	StateManager *baby.StateManager
+
+	commandHandlersMu sync.RWMutex
+	commandHandlers   map[string]CommandHandler
 }
 
 // NewConnection - constructor
 func NewConnection(opts Opts) *Connection {
 	return &Connection{
-		Opts: opts,
+		Opts:            opts,
+		commandHandlers: make(map[string]CommandHandler),
 	}
 }
 
+// RegisterCommandHandler - registers handler for a command topic, must be called before Run
+func (conn *Connection) RegisterCommandHandler(command string, handler CommandHandler) {
+	conn.commandHandlersMu.Lock()
+	conn.commandHandlers[command] = handler
+	conn.commandHandlersMu.Unlock()
+}
+
 // Run - runs the mqtt connection handler
 func (conn *Connection) Run(manager *baby.StateManager, ctx utils.GracefulContext) {
 	conn.StateManager = manager
@@ -78,10 +95,50 @@ func runMqtt(conn *Connection, attempt utils.AttemptContext) {
 		}
 	})
 
+	unsubscribeEvents := conn.StateManager.SubscribeEvents(func(babyUID string, event baby.Event) {
+		topic := fmt.Sprintf("%v/babies/%v/events/%v", conn.Opts.TopicPrefix, babyUID, event.Name)
+
+		payload, err := json.Marshal(event.AsMap())
+		if err != nil {
+			log.Error().Err(err).Msgf("Unable to marshal %v event", event.Name)
+			return
+		}
+
+		log.Trace().Str("topic", topic).Bytes("payload", payload).Msg("MQTT publish")
+
+		token := client.Publish(topic, 0, false, payload)
+		if token.Wait(); token.Error() != nil {
+			log.Error().Err(token.Error()).Msgf("Unable to publish %v event", event.Name)
+		}
+	})
+
+	conn.commandHandlersMu.RLock()
+	for command, handler := range conn.commandHandlers {
+		subscribeCommand(conn, client, command, handler)
+	}
+	conn.commandHandlersMu.RUnlock()
+
 	// Wait until interrupt signal is received
 	<-attempt.Done()
 
 	log.Debug().Msg("Closing MQTT connection on interrupt")
 	unsubscribe()
+	unsubscribeEvents()
 	client.Disconnect(250)
 }
+
+func subscribeCommand(conn *Connection, client MQTT.Client, command string, handler CommandHandler) {
+	topic := fmt.Sprintf("%v/babies/+/%v/set", conn.Opts.TopicPrefix, command)
+	topicPrefix := fmt.Sprintf("%v/babies/", conn.Opts.TopicPrefix)
+
+	token := client.Subscribe(topic, 0, func(_ MQTT.Client, msg MQTT.Message) {
+		babyUID := strings.SplitN(strings.TrimPrefix(msg.Topic(), topicPrefix), "/", 2)[0]
+		log.Debug().Str("topic", msg.Topic()).Bytes("payload", msg.Payload()).Msg("MQTT command received")
+
+		go handler(babyUID, msg.Payload())
+	})
+
+	if token.Wait(); token.Error() != nil {
+		log.Error().Str("topic", topic).Err(token.Error()).Msg("Unable to subscribe to command topic")
+	}
+}
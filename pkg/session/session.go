@@ -2,8 +2,10 @@ package session
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -23,12 +25,35 @@ type Session struct {
 	Babies              []baby.Baby `json:"babies"`
 	RefreshToken        string      `json:"refreshToken"`
 	LastSeenMessageTime time.Time   `json:"lastSeenMessageTime"`
+
+	// User camera tokens for local connection, keyed by camera UID
+	UCTokens map[string]UCToken `json:"ucTokens,omitempty"`
+
+	// Last known firmware of cams, keyed by camera UID
+	Firmware map[string]FirmwareInfo `json:"firmware,omitempty"`
+}
+
+// FirmwareInfo - firmware versions reported by a cam
+type FirmwareInfo struct {
+	CurrentVersion    string `json:"currentVersion"`
+	DownloadedVersion string `json:"downloadedVersion"`
+	UpgradeDownloaded bool   `json:"upgradeDownloaded"`
+	IsSecurityUpgrade bool   `json:"isSecurityUpgrade"`
+}
+
+// UCToken - user camera token
+type UCToken struct {
+	Token     string    `json:"token"`
+	FetchTime time.Time `json:"fetchTime"`
 }
 
 // Store - application session store context
+// Session shared by multiple goroutines should be accessed only through View / Update
 type Store struct {
 	Filename string
 	Session  *Session
+
+	mu sync.Mutex
 }
 
 // NewSessionStore - constructor
@@ -67,29 +92,51 @@ func (store *Store) Load() {
 
 }
 
+// Update - changes the session and stores it in a file, changes are serialized with other updates and saving
+func (store *Store) Update(mutate func(session *Session)) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	mutate(store.Session)
+	store.save()
+}
+
+// View - reads the session without interfering with concurrent updates
+func (store *Store) View(read func(session *Session)) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	read(store.Session)
+}
+
 // Save - stores current data in a file
 func (store *Store) Save() {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.save()
+}
+
+func (store *Store) save() {
 	if store.Filename == "" {
 		return
 	}
 
 	log.Trace().Str("filename", store.Filename).Msg("Storing app session to the file")
 
-	f, err := os.OpenFile(store.Filename, os.O_RDWR|os.O_CREATE, 0644)
-	if err != nil {
-		log.Fatal().Str("filename", store.Filename).Err(err).Msg("Unable to open app session file for writing")
-	}
-
-	defer f.Close()
-
 	data, jsonErr := json.Marshal(store.Session)
 	if jsonErr != nil {
 		log.Fatal().Str("filename", store.Filename).Err(jsonErr).Msg("Unable to marshal contents of app session file")
 	}
 
-	_, writeErr := f.Write(data)
-	if writeErr != nil {
-		log.Fatal().Str("filename", store.Filename).Err(writeErr).Msg("Unable to wrote to app session file")
+	// Written through temporary file, so that the session file is never left half written
+	tmpFilename := store.Filename + ".tmp"
+	if writeErr := ioutil.WriteFile(tmpFilename, data, 0644); writeErr != nil {
+		log.Fatal().Str("filename", tmpFilename).Err(writeErr).Msg("Unable to write to app session file")
+	}
+
+	if renameErr := os.Rename(tmpFilename, store.Filename); renameErr != nil {
+		log.Fatal().Str("filename", store.Filename).Err(renameErr).Msg("Unable to replace app session file")
 	}
 }
 
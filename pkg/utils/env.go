@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -67,6 +68,28 @@ func EnvVarSeconds(varName string, defaultValue time.Duration) time.Duration {
 	return value
 }
 
+// EnvVarMap - retrieves value of environment variable in key1=value1,key2=value2 format, fails if variable contains malformed pair
+func EnvVarMap(varName string) map[string]string {
+	m := make(map[string]string)
+	valueStr := EnvVarStr(varName, "")
+
+	for _, pair := range strings.Split(valueStr, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			log.Fatal().Msgf("Unexpected value %v for environment variable %v (expected key=value pairs)", pair, varName)
+		}
+
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return m
+}
+
 // LoadDotEnvFile - Loads environment variables from .env file in the current working directory (if found)
 func LoadDotEnvFile() {
 	absFilepath, filePathErr := filepath.Abs(".env")